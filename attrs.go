@@ -33,40 +33,35 @@ func (a Attrs) Update(other Attrs) {
 }
 
 func (a Attrs) encode(b *bytes.Buffer) {
-	total := len(a)
-	keys := make([]string, 0, total)
-	for k := range a {
-		keys = append(keys, k)
-	}
-	sortKeys(keys)
-
-	for i, key := range keys {
-		writeQueryEscape(key, b)
-
-		b.WriteByte('=')
-		b.WriteByte('\'')
+	ab := getBuilder()
+	defer putBuilder(ab)
 
-		writePathEscape(a[key], b)
+	ab.AddAttrs(a)
+	ab.sort()
+	encodeAttrs(ab.attrs, b)
+}
 
-		b.WriteByte('\'')
-		if i < total-1 {
+// encodeAttrs encodes attrs sorted by key, pairs encoded in advance are written as they are.
+func encodeAttrs(attrs []builderAttr, b *bytes.Buffer) {
+	for i, attr := range attrs {
+		if i > 0 {
 			b.WriteByte(',')
 		}
+		if attr.encoded != "" {
+			b.WriteString(attr.encoded)
+			continue
+		}
+		writeAttr(attr.Key, attr.Value, b)
 	}
 }
 
-// sortKeys implements a simple insertion sort on string slice.
-// We save one alloc by not using sort.Strings.
-func sortKeys(keys []string) {
-	for i := 1; i < len(keys); i++ {
-		if keys[i] < keys[i-1] {
-			j := i - 1
-			temp := keys[i]
-			for j >= 0 && keys[j] > temp {
-				keys[j+1] = keys[j]
-				j--
-			}
-			keys[j+1] = temp
-		}
-	}
+func writeAttr(key string, value string, b *bytes.Buffer) {
+	writeQueryEscape(key, b)
+
+	b.WriteByte('=')
+	b.WriteByte('\'')
+
+	writePathEscape(value, b)
+
+	b.WriteByte('\'')
 }
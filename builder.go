@@ -0,0 +1,171 @@
+package sqlcommenter
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// AttrAppender is implemented by providers which append attrs to AttrBuilder
+// instead of building Attrs map on every call.
+type AttrAppender interface {
+	AppendAttrs(ctx context.Context, b *AttrBuilder)
+}
+
+// AttrAppenderFunc adapts func to AttrAppender.
+type AttrAppenderFunc func(ctx context.Context, b *AttrBuilder)
+
+// AppendAttrs appends attrs to b.
+func (f AttrAppenderFunc) AppendAttrs(ctx context.Context, b *AttrBuilder) {
+	f(ctx, b)
+}
+
+var (
+	_ AttrAppender = staticAttrs(nil)
+	_ AttrAppender = attrAppender{}
+)
+
+// attrAppender adapts AttrAppender to AttrProvider.
+type attrAppender struct {
+	app AttrAppender
+}
+
+// GetAttrs returns Attrs appended by AttrAppender.
+func (a attrAppender) GetAttrs(ctx context.Context) Attrs {
+	b := getBuilder()
+	defer putBuilder(b)
+
+	a.app.AppendAttrs(ctx, b)
+	return b.toAttrs()
+}
+
+// AppendAttrs appends attrs to b.
+func (a attrAppender) AppendAttrs(ctx context.Context, b *AttrBuilder) {
+	a.app.AppendAttrs(ctx, b)
+}
+
+// builderAttr is attr collected by AttrBuilder.
+type builderAttr struct {
+	Attr
+	// encoded is key value pair escaped in advance by static providers.
+	encoded string
+}
+
+// encodedAttr returns attr with key value pair escaped in advance.
+func encodedAttr(key string, value string) builderAttr {
+	var b bytes.Buffer
+	writeAttr(key, value, &b)
+	return builderAttr{Attr: Attr{Key: key, Value: value}, encoded: b.String()}
+}
+
+// staticAttrs provides the same attrs for every query.
+// Attrs are sorted by key and their key value pairs are escaped in advance.
+type staticAttrs []builderAttr
+
+func newStaticAttrs(attrs Attrs) staticAttrs {
+	static := make(staticAttrs, 0, len(attrs))
+	for k, v := range attrs {
+		static = append(static, encodedAttr(k, v))
+	}
+	b := &AttrBuilder{attrs: static}
+	b.sort()
+	return static
+}
+
+// GetAttrs returns Attrs.
+func (a staticAttrs) GetAttrs(ctx context.Context) Attrs {
+	attrs := make(Attrs, len(a))
+	for _, attr := range a {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+// AppendAttrs appends attrs to b.
+func (a staticAttrs) AppendAttrs(ctx context.Context, b *AttrBuilder) {
+	b.attrs = append(b.attrs, a...)
+}
+
+// AttrBuilder collects attrs of a single query. Keys may be appended multiple times,
+// duplicates are resolved according to MergeMode in the order in which they were appended.
+// AttrBuilder is reused between queries and must not be retained by providers.
+type AttrBuilder struct {
+	attrs []builderAttr
+}
+
+// Add appends attr with key and value.
+func (b *AttrBuilder) Add(key string, value string) {
+	b.attrs = append(b.attrs, builderAttr{Attr: Attr{Key: key, Value: value}})
+}
+
+// AddAttr appends attrs.
+func (b *AttrBuilder) AddAttr(attrs ...Attr) {
+	for _, attr := range attrs {
+		b.attrs = append(b.attrs, builderAttr{Attr: attr})
+	}
+}
+
+// AddAttrs appends all attrs from map.
+func (b *AttrBuilder) AddAttrs(attrs Attrs) {
+	for k, v := range attrs {
+		b.attrs = append(b.attrs, builderAttr{Attr: Attr{Key: k, Value: v}})
+	}
+}
+
+// sort sorts attrs by key in place, keeping attrs with the same key in the order they were appended.
+func (b *AttrBuilder) sort() {
+	attrs := b.attrs
+	for i := 1; i < len(attrs); i++ {
+		if attrs[i].Key < attrs[i-1].Key {
+			j := i - 1
+			temp := attrs[i]
+			for j >= 0 && attrs[j].Key > temp.Key {
+				attrs[j+1] = attrs[j]
+				j--
+			}
+			attrs[j+1] = temp
+		}
+	}
+}
+
+// remove removes attrs for which fn reports true, keeping order of others.
+func (b *AttrBuilder) remove(fn func(attr Attr) bool) {
+	n := 0
+	for _, attr := range b.attrs {
+		if !fn(attr.Attr) {
+			b.attrs[n] = attr
+			n++
+		}
+	}
+	clear(b.attrs[n:])
+	b.attrs = b.attrs[:n]
+}
+
+// toAttrs returns collected attrs as Attrs.
+func (b *AttrBuilder) toAttrs() Attrs {
+	attrs := make(Attrs, len(b.attrs))
+	for _, attr := range b.attrs {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func (b *AttrBuilder) reset() {
+	clear(b.attrs)
+	b.attrs = b.attrs[:0]
+}
+
+var builderPool = sync.Pool{
+	New: func() interface{} {
+		return &AttrBuilder{attrs: make([]builderAttr, 0, 16)}
+	},
+}
+
+func getBuilder() *AttrBuilder {
+	return builderPool.Get().(*AttrBuilder)
+}
+
+func putBuilder(b *AttrBuilder) {
+	b.reset()
+	builderPool.Put(b)
+}
@@ -0,0 +1,233 @@
+package sqlcommenter
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestAttrBuilderSort(t *testing.T) {
+	b := &AttrBuilder{}
+	b.Add("route", "/users")
+	b.AddAttr(Attr{Key: "app", Value: "api"}, Attr{Key: "route", Value: "/orders"})
+	b.Add("action", "list")
+	b.sort()
+
+	want := []Attr{
+		{Key: "action", Value: "list"},
+		{Key: "app", Value: "api"},
+		{Key: "route", Value: "/users"},
+		{Key: "route", Value: "/orders"},
+	}
+	if got := builtAttrs(b); !reflect.DeepEqual(want, got) {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func TestAttrBuilderRemove(t *testing.T) {
+	b := &AttrBuilder{}
+	b.Add("a", "1")
+	b.Add("b", "2")
+	b.Add("c", "3")
+	b.remove(func(attr Attr) bool {
+		return attr.Key == "b"
+	})
+
+	want := []Attr{{Key: "a", Value: "1"}, {Key: "c", Value: "3"}}
+	if got := builtAttrs(b); !reflect.DeepEqual(want, got) {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+// builtAttrs returns attrs collected by b.
+func builtAttrs(b *AttrBuilder) []Attr {
+	attrs := make([]Attr, len(b.attrs))
+	for i, attr := range b.attrs {
+		attrs[i] = attr.Attr
+	}
+	return attrs
+}
+
+func TestAttrAppender(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "appender",
+			opts: []Option{
+				WithAttrAppender(AttrAppenderFunc(func(ctx context.Context, b *AttrBuilder) {
+					b.Add("route", "/users")
+					b.AddAttr(Int("shard", 3))
+				})),
+			},
+			want: "SELECT 1 /*route='%2Fusers',shard='3'*/",
+		},
+		{
+			name: "appender last wins",
+			opts: []Option{
+				WithAttrPairs("route", "/orders"),
+				WithAttrAppender(AttrAppenderFunc(func(ctx context.Context, b *AttrBuilder) {
+					b.Add("route", "/users")
+				})),
+			},
+			want: "SELECT 1 /*route='%2Fusers'*/",
+		},
+		{
+			name: "appender first wins",
+			opts: []Option{
+				WithMergeMode(MergeFirstWins),
+				WithAttrPairs("route", "/orders"),
+				WithAttrAppender(AttrAppenderFunc(func(ctx context.Context, b *AttrBuilder) {
+					b.Add("route", "/users")
+				})),
+			},
+			want: "SELECT 1 /*route='%2Forders'*/",
+		},
+		{
+			name: "appender drop conflicts",
+			opts: []Option{
+				WithMergeMode(MergeDropConflicts),
+				WithAttrPairs("route", "/orders", "app", "api"),
+				WithAttrAppender(AttrAppenderFunc(func(ctx context.Context, b *AttrBuilder) {
+					b.Add("route", "/users")
+				})),
+				WithAttrPairs("route", "/orders"),
+			},
+			want: "SELECT 1 /*app='api'*/",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got := Comment(context.Background(), "SELECT 1", cs.opts...)
+			if want := cs.want; want != got {
+				t.Errorf("got '%v', want '%v'", got, want)
+			}
+		})
+	}
+}
+
+func TestAttrAppenderGetAttrs(t *testing.T) {
+	prov := attrAppender{app: AttrAppenderFunc(func(ctx context.Context, b *AttrBuilder) {
+		b.Add("route", "/users")
+	})}
+
+	got := prov.GetAttrs(context.Background())
+	if want := (Attrs{"route": "/users"}); !reflect.DeepEqual(want, got) {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func BenchmarkCommentAppender(b *testing.B) {
+	ctx := context.Background()
+	cmt := newCommenter(
+		WithAttrAppender(AttrAppenderFunc(func(ctx context.Context, b *AttrBuilder) {
+			b.Add("route", "/users/{id}")
+			b.Add("controller", "users")
+			b.Add("action", "show")
+		})),
+		WithAttrPairs("application", "hello-app"),
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cmt.comment(ctx, "SELECT * FROM users WHERE id = $1")
+	}
+}
+
+func TestStaticAttrs(t *testing.T) {
+	cases := []struct {
+		name string
+		ctx  context.Context
+		opts []Option
+		want string
+	}{
+		{
+			name: "static",
+			opts: []Option{WithAttrs(Attrs{"route": "/users/{id}", "app": "hello app"})},
+			want: "SELECT 1 /*app='hello%20app',route='%2Fusers%2F%7Bid%7D'*/",
+		},
+		{
+			name: "static with dynamic",
+			ctx:  ContextWithAttrs(context.Background(), Attrs{"request": "a b"}),
+			opts: []Option{
+				WithAttrPairs("app", "hello app"),
+				WithAttrFunc(func(ctx context.Context) Attrs {
+					return Attrs{"action": "get user"}
+				}),
+			},
+			want: "SELECT 1 /*action='get%20user',app='hello%20app',request='a%20b'*/",
+		},
+		{
+			name: "static conflict last wins",
+			opts: []Option{
+				WithAttrPairs("app", "first app"),
+				WithAttrFunc(func(ctx context.Context) Attrs {
+					return Attrs{"app": "second app"}
+				}),
+				WithAttrPairs("app", "third app"),
+			},
+			want: "SELECT 1 /*app='third%20app'*/",
+		},
+		{
+			name: "static redacted",
+			opts: []Option{
+				WithAttrPairs("app", "hello app", "token", "secret value"),
+				WithRedaction(regexp.MustCompile("^secret"), RedactReplace),
+			},
+			want: "SELECT 1 /*app='hello%20app',token='REDACTED'*/",
+		},
+		{
+			name: "static truncated",
+			opts: []Option{
+				WithAttrPairs("app", "hello app"),
+				WithMaxValueLength(5),
+			},
+			want: "SELECT 1 /*app='hello'*/",
+		},
+		{
+			name: "static provider",
+			opts: []Option{
+				WithStaticAttrProvider(AttrProviderFunc(func(ctx context.Context) Attrs {
+					return Attrs{"version": "v1.2.3+build"}
+				})),
+			},
+			want: "SELECT 1 /*version='v1.2.3+build'*/",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			ctx := cs.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			got := Comment(ctx, "SELECT 1", cs.opts...)
+			if want := cs.want; want != got {
+				t.Errorf("got '%v', want '%v'", got, want)
+			}
+		})
+	}
+}
+
+func TestStaticAttrsCopied(t *testing.T) {
+	attrs := Attrs{"app": "hello"}
+	cmt := NewCommenter(WithAttrs(attrs))
+	attrs["app"] = "changed"
+
+	if got, want := cmt.Comment(context.Background(), "SELECT 1"), "SELECT 1 /*app='hello'*/"; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func TestStaticAttrsGetAttrs(t *testing.T) {
+	got := newStaticAttrs(Attrs{"b": "2", "a": "1"}).GetAttrs(context.Background())
+	if want := (Attrs{"a": "1", "b": "2"}); !reflect.DeepEqual(want, got) {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package sqlcommenter
+
+import (
+	"bytes"
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// NewQueryCache returns QueryCache holding at most size commented queries.
+func NewQueryCache(size int) *QueryCache {
+	if size < 1 {
+		size = 1
+	}
+	return &QueryCache{
+		size:  size,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+// QueryCache is a bounded LRU cache of commented queries keyed by query and encoded attrs,
+// see WithQueryCache. It is safe for concurrent use and may be shared by multiple commenters.
+type QueryCache struct {
+	size   int
+	mu     sync.Mutex
+	items  map[string]*list.Element
+	order  *list.List
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type cacheEntry struct {
+	key   string
+	value string
+}
+
+// Hits returns number of queries found in cache.
+func (c *QueryCache) Hits() uint64 {
+	return c.hits.Load()
+}
+
+// Misses returns number of queries not found in cache.
+func (c *QueryCache) Misses() uint64 {
+	return c.misses.Load()
+}
+
+// Len returns number of cached queries.
+func (c *QueryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *QueryCache) get(key []byte) (string, bool) {
+	c.mu.Lock()
+	var value string
+	el, ok := c.items[string(key)]
+	if ok {
+		c.order.MoveToFront(el)
+		value = el.Value.(*cacheEntry).value
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		c.misses.Add(1)
+		return "", false
+	}
+	c.hits.Add(1)
+	return value, true
+}
+
+func (c *QueryCache) add(key string, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheEntry).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, value: value})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// writeCacheKey writes key identifying commented query to buf.
+// Settings affecting placement of comment are included, as commenter options
+// may be overridden per query by WithCommentOptions.
+func (c *commenter) writeCacheKey(query string, attrs []builderAttr, buf *bytes.Buffer) {
+	buf.WriteByte(byte(c.positionMode))
+	buf.WriteByte(byte(c.existingMode))
+	buf.WriteByte(byte(c.dialect))
+	buf.WriteString(query)
+	// encoded attrs never contain zero byte
+	buf.WriteByte(0)
+	encodeAttrs(attrs, buf)
+}
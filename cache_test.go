@@ -0,0 +1,183 @@
+package sqlcommenter
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestQueryCache(t *testing.T) {
+	cache := NewQueryCache(2)
+	cmt := NewCommenter(WithQueryCache(cache), WithAttrPairs("app", "api"))
+
+	cases := []struct {
+		ctx        context.Context
+		query      string
+		want       string
+		wantHits   uint64
+		wantMisses uint64
+	}{
+		{
+			ctx:        context.Background(),
+			query:      "SELECT 1",
+			want:       "SELECT 1 /*app='api'*/",
+			wantMisses: 1,
+		},
+		{
+			ctx:        context.Background(),
+			query:      "SELECT 1",
+			want:       "SELECT 1 /*app='api'*/",
+			wantHits:   1,
+			wantMisses: 1,
+		},
+		{
+			ctx:        ContextWithAttrs(context.Background(), Attrs{"job": "cleanup"}),
+			query:      "SELECT 1",
+			want:       "SELECT 1 /*app='api',job='cleanup'*/",
+			wantHits:   1,
+			wantMisses: 2,
+		},
+		{
+			ctx:        WithCommentOptions(context.Background(), WithPositionMode(PositionPrefix)),
+			query:      "SELECT 1",
+			want:       "/*app='api'*/ SELECT 1",
+			wantHits:   1,
+			wantMisses: 3,
+		},
+		{
+			ctx:        context.Background(),
+			query:      "SELECT 1",
+			want:       "SELECT 1 /*app='api'*/",
+			wantHits:   1,
+			wantMisses: 4,
+		},
+		{
+			ctx:        WithCommentOptions(context.Background(), WithPositionMode(PositionPrefix)),
+			query:      "SELECT 1",
+			want:       "/*app='api'*/ SELECT 1",
+			wantHits:   2,
+			wantMisses: 4,
+		},
+	}
+
+	for _, cs := range cases {
+		if got := cmt.Comment(cs.ctx, cs.query); cs.want != got {
+			t.Errorf("got '%v', want '%v'", got, cs.want)
+		}
+		if got := cache.Hits(); cs.wantHits != got {
+			t.Errorf("got hits '%v', want '%v'", got, cs.wantHits)
+		}
+		if got := cache.Misses(); cs.wantMisses != got {
+			t.Errorf("got misses '%v', want '%v'", got, cs.wantMisses)
+		}
+	}
+	if got, want := cache.Len(), 2; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func TestQueryCacheEviction(t *testing.T) {
+	cache := NewQueryCache(2)
+	cache.add("a", "1")
+	cache.add("b", "2")
+	if _, ok := cache.get([]byte("a")); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	cache.add("c", "3")
+
+	if _, ok := cache.get([]byte("b")); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get([]byte(key)); !ok {
+			t.Errorf("expected %v to be cached", key)
+		}
+	}
+	if got, want := cache.Len(), 2; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func TestQueryCacheUncommented(t *testing.T) {
+	cache := NewQueryCache(10)
+	cmt := NewCommenter(WithQueryCache(cache))
+
+	if got, want := cmt.Comment(context.Background(), "SELECT 1"), "SELECT 1"; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+	if got := cache.Hits() + cache.Misses(); got != 0 {
+		t.Errorf("got '%v', want '%v'", got, 0)
+	}
+}
+
+func TestQueryCacheConcurrent(t *testing.T) {
+	cache := NewQueryCache(4)
+	cmt := NewCommenter(WithQueryCache(cache), WithAttrPairs("app", "api"))
+	queries := []string{"SELECT 1", "SELECT 2", "SELECT 3", "SELECT 4", "SELECT 5"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				query := queries[j%len(queries)]
+				if got, want := cmt.Comment(context.Background(), query), query+" /*app='api'*/"; want != got {
+					t.Errorf("got '%v', want '%v'", got, want)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := cache.Hits()+cache.Misses(), uint64(800); want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func TestQueryCacheConcurrentAdd(t *testing.T) {
+	cache := NewQueryCache(1)
+	key := []byte("SELECT 1")
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			// both goroutines may miss and add the same key, updating entry read by the other one
+			for j := 0; j < 1000; j++ {
+				_, _ = cache.get(key)
+				cache.add(string(key), "SELECT 1 /*app='api'*/")
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if got, want := cache.Len(), 1; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func BenchmarkCommentCache(b *testing.B) {
+	ctx := ContextWithAttrs(context.Background(), Attrs{"request": "22"})
+	cmt := newCommenter(
+		WithQueryCache(NewQueryCache(100)),
+		WithAttrs(map[string]string{
+			"application": "hello app",
+			"version":     "v1.2.3+build/45",
+			"region":      "eu-west-1",
+			"host":        "api-7f9c/pod 3",
+		}),
+		WithAttrPairs("db_driver", "pgx/v5", "framework", "net/http", "team", "growth & billing"),
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cmt.comment(ctx, "SELECT * FROM my_table WHERE column IS NOT NULL")
+	}
+}
@@ -3,7 +3,8 @@ package sqlcommenter
 import (
 	"bytes"
 	"context"
-	"strings"
+	"maps"
+	"slices"
 	"sync"
 )
 
@@ -13,16 +14,31 @@ const (
 )
 
 // Comment adds comments to query using provided options.
+// Attrs stored in ctx by ContextWithAttrs are always included.
 func Comment(ctx context.Context, query string, opts ...Option) string {
-	if len(opts) == 0 {
-		return query
-	}
-	if strings.Contains(query, commentStart) {
+	if len(opts) == 0 && len(AttrsFromContext(ctx)) == 0 && len(optionsFromContext(ctx)) == 0 {
 		return query
 	}
 	return newCommenter(opts...).comment(ctx, query)
 }
 
+// NewCommenter returns Commenter configured with provided options.
+// It is intended for integrations which do not go through database/sql.
+func NewCommenter(opts ...Option) *Commenter {
+	return &Commenter{cmt: newCommenter(opts...)}
+}
+
+// Commenter adds comments to queries using options provided once in NewCommenter.
+type Commenter struct {
+	cmt *commenter
+}
+
+// Comment adds comments to query.
+// Attrs stored in ctx by ContextWithAttrs are always included.
+func (c *Commenter) Comment(ctx context.Context, query string) string {
+	return c.cmt.comment(ctx, query)
+}
+
 func newCommenter(opts ...Option) *commenter {
 	cmt := &commenter{}
 	for _, opt := range opts {
@@ -31,43 +47,325 @@ func newCommenter(opts ...Option) *commenter {
 	return cmt
 }
 
+// with returns copy of commenter with opts applied.
+func (c *commenter) with(opts []Option) *commenter {
+	cmt := *c
+	cmt.providers = slices.Clip(cmt.providers)
+	cmt.redactions = slices.Clip(cmt.redactions)
+	cmt.alwaysComment = slices.Clip(cmt.alwaysComment)
+	cmt.skip = slices.Clip(cmt.skip)
+	cmt.sessionStmts = slices.Clip(cmt.sessionStmts)
+	cmt.pinnedKeys = maps.Clone(cmt.pinnedKeys)
+	cmt.allowedKeys = maps.Clone(cmt.allowedKeys)
+	cmt.deniedKeys = maps.Clone(cmt.deniedKeys)
+	cmt.keyPriority = maps.Clone(cmt.keyPriority)
+	cmt.volatileKeys = maps.Clone(cmt.volatileKeys)
+	for _, opt := range opts {
+		opt(&cmt)
+	}
+	return &cmt
+}
+
 type commenter struct {
-	providers []AttrProvider
+	providers    []AttrProvider
+	prepareMode  PrepareMode
+	txMode       TxMode
+	existingMode ExistingCommentMode
+	positionMode PositionMode
+	dialect      Dialect
+	dialectSet   bool
+	mergeMode    MergeMode
+	pinnedKeys   map[string]struct{}
+	conflictFunc ConflictFunc
+	allowedKeys  map[string]struct{}
+	deniedKeys   map[string]struct{}
+	redactions   []redaction
+	redactionKey []byte
+
+	maxValueLen   int
+	maxCommentLen int
+	keyPriority   map[string]int
+	truncateFunc  TruncateFunc
+
+	sampler       Sampler
+	alwaysComment []QueryPredicate
+	skip          []QueryPredicate
+
+	volatileKeys map[string]struct{}
+	volatileStmt StatementFunc
+	sessionStmts []sessionStatement
+
+	cache *QueryCache
 }
 
 func (c *commenter) comment(ctx context.Context, query string) string {
-	attrs := c.attrs(ctx)
-	if len(attrs) == 0 {
-		return query
+	commented, _ := c.commentSplit(ctx, query)
+	return commented
+}
+
+// commentSplit comments query, returning also volatile attrs excluded from comment.
+func (c *commenter) commentSplit(ctx context.Context, query string) (string, Attrs) {
+	c, ok := c.forQuery(ctx, query)
+	if !ok {
+		return query, nil
+	}
+
+	b := getBuilder()
+	defer putBuilder(b)
+
+	vb := getBuilder()
+	defer putBuilder(vb)
+
+	c.collect(ctx, query, b)
+	c.filter(b)
+	c.split(b, vb)
+	c.limit(b)
+	c.limit(vb)
+
+	var volatile Attrs
+	if len(vb.attrs) > 0 {
+		volatile = vb.toAttrs()
+	}
+	if len(b.attrs) == 0 {
+		return query, volatile
+	}
+	return c.write(query, b.attrs), volatile
+}
+
+// volatileAttrs returns volatile attrs of query without commenting it.
+func (c *commenter) volatileAttrs(ctx context.Context, query string) Attrs {
+	c, ok := c.forQuery(ctx, query)
+	if !ok || len(c.volatileKeys) == 0 {
+		return nil
 	}
 
+	b := getBuilder()
+	defer putBuilder(b)
+	vb := getBuilder()
+	defer putBuilder(vb)
+
+	c.collect(ctx, query, b)
+	c.filter(b)
+	c.split(b, vb)
+	c.limit(vb)
+	if len(vb.attrs) == 0 {
+		return nil
+	}
+	return vb.toAttrs()
+}
+
+// forQuery returns commenter with options from ctx applied
+// and reports whether query should be commented.
+func (c *commenter) forQuery(ctx context.Context, query string) (*commenter, bool) {
+	if skipFromContext(ctx) {
+		return c, false
+	}
+	if opts := optionsFromContext(ctx); len(opts) > 0 {
+		c = c.with(opts)
+	}
+	if c.skipped(ctx, query) || !c.sampled(ctx, query) {
+		return c, false
+	}
+	if c.existingMode == ExistingCommentSkip && c.dialect.hasComment(query) {
+		return c, false
+	}
+	return c, true
+}
+
+func (c *commenter) write(query string, attrs []builderAttr) string {
 	buf := bufPool.Get().(*bytes.Buffer)
 	defer func() {
 		buf.Reset()
 		bufPool.Put(buf)
 	}()
 
-	buf.WriteString(query)
-	buf.WriteByte(' ')
+	if c.cache == nil {
+		return c.render(query, attrs, buf)
+	}
+
+	c.writeCacheKey(query, attrs, buf)
+	if commented, ok := c.cache.get(buf.Bytes()); ok {
+		return commented
+	}
+	key := buf.String()
+	buf.Reset()
+
+	commented := c.render(query, attrs, buf)
+	c.cache.add(key, commented)
+	return commented
+}
+
+// render renders query commented with attrs using buf.
+func (c *commenter) render(query string, attrs []builderAttr, buf *bytes.Buffer) string {
+	if c.existingMode == ExistingCommentMerge && c.merge(query, attrs, buf) {
+		return buf.String()
+	}
+
+	if c.positionMode == PositionPrefix {
+		// hints must stay at the beginning of query
+		if end := c.dialect.leadingHints(query); end > 0 {
+			buf.WriteString(query[:end])
+			buf.WriteByte(' ')
+			writeComment(attrs, buf)
+			buf.WriteByte(' ')
+			buf.WriteString(trimLeftSpace(query[end:]))
+			return buf.String()
+		}
+		writeComment(attrs, buf)
+		buf.WriteByte(' ')
+		buf.WriteString(query)
+		return buf.String()
+	}
+
+	body, trailer := c.dialect.splitTrailer(query)
+	buf.WriteString(body)
+	if span, ok := c.dialect.trailingComment(body); ok && span.line {
+		// line comment would swallow our comment
+		buf.WriteByte('\n')
+	} else {
+		buf.WriteByte(' ')
+	}
+	writeComment(attrs, buf)
+	buf.WriteString(trailer)
+	return buf.String()
+}
+
+// merge merges attrs into existing sqlcommenter comment placed according to positionMode.
+// Keys already present in query are left untouched.
+func (c *commenter) merge(query string, attrs []builderAttr, buf *bytes.Buffer) bool {
+	var span commentSpan
+	var ok bool
+	if c.positionMode == PositionPrefix {
+		span, ok = c.dialect.leadingComment(query)
+	} else {
+		body, _ := c.dialect.splitTrailer(query)
+		span, ok = c.dialect.trailingComment(body)
+	}
+	if !ok {
+		return false
+	}
+	content, ok := span.blockBody(query)
+	if !ok {
+		return false
+	}
+
+	existing, err := parseAttrs(content)
+	if err != nil {
+		return false
+	}
+	for _, attr := range attrs {
+		if _, ok := existing[attr.Key]; !ok {
+			existing[attr.Key] = attr.Value
+		}
+	}
+
+	buf.WriteString(query[:span.start])
 	buf.WriteString(commentStart)
-	attrs.encode(buf)
+	existing.encode(buf)
 	buf.WriteString(commentEnd)
-	return buf.String()
+	buf.WriteString(query[span.end:])
+	return true
 }
 
-func (c *commenter) attrs(ctx context.Context) Attrs {
-	switch len(c.providers) {
-	case 0:
-		return nil
-	case 1:
-		return c.providers[0].GetAttrs(ctx)
+// writeComment writes comment encoded from attrs sorted by key.
+func writeComment(attrs []builderAttr, buf *bytes.Buffer) {
+	buf.WriteString(commentStart)
+	encodeAttrs(attrs, buf)
+	buf.WriteString(commentEnd)
+}
+
+// collect appends attrs of providers followed by attrs stored in ctx to b,
+// sorts them by key and resolves duplicate keys.
+func (c *commenter) collect(ctx context.Context, query string, b *AttrBuilder) {
+	for _, prov := range c.providers {
+		appendProviderAttrs(ctx, query, c.dialect, prov, b)
+	}
+	b.AddAttrs(AttrsFromContext(ctx))
+	b.sort()
+	c.resolve(b)
+}
+
+// appendProviderAttrs appends attrs of prov to b, passing query to providers implementing QueryAttrProvider
+// and dialect d to providers depending on it.
+func appendProviderAttrs(ctx context.Context, query string, d Dialect, prov AttrProvider, b *AttrBuilder) {
+	switch p := prov.(type) {
+	case AttrAppender:
+		p.AppendAttrs(ctx, b)
+	case dialectQueryAttrProvider:
+		b.AddAttrs(p.getDialectQueryAttrs(ctx, query, d))
+	case QueryAttrProvider:
+		b.AddAttrs(p.GetQueryAttrs(ctx, query))
 	default:
-		attrs := make(Attrs)
-		for _, prov := range c.providers {
-			attrs.Update(prov.GetAttrs(ctx))
+		b.AddAttrs(prov.GetAttrs(ctx))
+	}
+}
+
+// dialectQueryAttrProvider is implemented by query attr providers which parse query according to dialect.
+type dialectQueryAttrProvider interface {
+	getDialectQueryAttrs(ctx context.Context, query string, d Dialect) Attrs
+}
+
+// queryAttrProvider adapts QueryAttrProvider to AttrProvider.
+type queryAttrProvider struct {
+	prov QueryAttrProvider
+}
+
+// GetAttrs returns no Attrs as there is no query to compute them from.
+func (p queryAttrProvider) GetAttrs(ctx context.Context) Attrs {
+	return nil
+}
+
+// GetQueryAttrs returns Attrs.
+func (p queryAttrProvider) GetQueryAttrs(ctx context.Context, query string) Attrs {
+	return p.prov.GetQueryAttrs(ctx, query)
+}
+
+func (p queryAttrProvider) getDialectQueryAttrs(ctx context.Context, query string, d Dialect) Attrs {
+	if prov, ok := p.prov.(dialectQueryAttrProvider); ok {
+		return prov.getDialectQueryAttrs(ctx, query, d)
+	}
+	return p.prov.GetQueryAttrs(ctx, query)
+}
+
+// resolve resolves duplicate keys of sorted attrs in b according to mergeMode and pinnedKeys,
+// keeping single attr for each key.
+func (c *commenter) resolve(b *AttrBuilder) {
+	attrs := b.attrs
+	n := 0
+	for i := 0; i < len(attrs); {
+		key, current := attrs[i].Key, i
+		dropped := false
+
+		j := i + 1
+		for ; j < len(attrs) && attrs[j].Key == key; j++ {
+			if dropped || attrs[current].Value == attrs[j].Value {
+				continue
+			}
+
+			if c.conflictFunc != nil {
+				c.conflictFunc(key, attrs[current].Value, attrs[j].Value)
+			}
+			if _, ok := c.pinnedKeys[key]; ok {
+				continue
+			}
+
+			switch c.mergeMode {
+			case MergeFirstWins:
+			case MergeDropConflicts:
+				dropped = true
+			default:
+				current = j
+			}
+		}
+
+		if !dropped {
+			attrs[n] = attrs[current]
+			n++
 		}
-		return attrs
+		i = j
 	}
+	clear(attrs[n:])
+	b.attrs = attrs[:n]
 }
 
 var bufPool = sync.Pool{
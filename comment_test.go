@@ -2,6 +2,8 @@ package sqlcommenter
 
 import (
 	"context"
+	"reflect"
+	"sort"
 	"sync"
 	"testing"
 )
@@ -43,6 +45,132 @@ func TestComment(t *testing.T) {
 			opts:  []Option{WithAttrPairs("key", "1value", "key2", "  value 2")},
 			want:  "SELECT 1 /*key='1value',key2='%20%20value%202'*/",
 		},
+		{
+			name:  "query with comment",
+			query: "SELECT /*+ IndexScan(t) */ * FROM t",
+			opts:  []Option{WithAttrPairs("key", "value")},
+			want:  "SELECT /*+ IndexScan(t) */ * FROM t /*key='value'*/",
+		},
+		{
+			name:  "query with comment skip",
+			query: "SELECT /*+ IndexScan(t) */ * FROM t",
+			opts:  []Option{WithAttrPairs("key", "value"), WithExistingCommentMode(ExistingCommentSkip)},
+			want:  "SELECT /*+ IndexScan(t) */ * FROM t",
+		},
+		{
+			name:  "query with comment in string literal",
+			query: "SELECT '/* literal */'",
+			opts:  []Option{WithAttrPairs("key", "value")},
+			want:  "SELECT '/* literal */' /*key='value'*/",
+		},
+		{
+			name:  "query with comment in dollar quoted body",
+			query: "SELECT $tag$ /* body */ $tag$",
+			opts:  []Option{WithAttrPairs("key", "value")},
+			want:  "SELECT $tag$ /* body */ $tag$ /*key='value'*/",
+		},
+		{
+			name:  "query with comment append",
+			query: "SELECT /*+ IndexScan(t) */ * FROM t",
+			opts:  []Option{WithAttrPairs("key", "value"), WithExistingCommentMode(ExistingCommentAppend)},
+			want:  "SELECT /*+ IndexScan(t) */ * FROM t /*key='value'*/",
+		},
+		{
+			name:  "query with line comment append",
+			query: "SELECT 1 -- comment",
+			opts:  []Option{WithAttrPairs("key", "value"), WithExistingCommentMode(ExistingCommentAppend)},
+			want:  "SELECT 1 -- comment\n/*key='value'*/",
+		},
+		{
+			name:  "query with sqlcommenter comment merge",
+			query: "SELECT 1 /*app='first',key='old'*/",
+			opts:  []Option{WithAttrPairs("key", "new", "route", "/users"), WithExistingCommentMode(ExistingCommentMerge)},
+			want:  "SELECT 1 /*app='first',key='old',route='%2Fusers'*/",
+		},
+		{
+			name:  "query with sqlcommenter comment and whitespace merge",
+			query: "SELECT 1 /*app='first'*/ \n",
+			opts:  []Option{WithAttrPairs("key", "value"), WithExistingCommentMode(ExistingCommentMerge)},
+			want:  "SELECT 1 /*app='first',key='value'*/ \n",
+		},
+		{
+			name:  "query with hint comment merge",
+			query: "SELECT 1 /*+ SeqScan(t) */",
+			opts:  []Option{WithAttrPairs("key", "value"), WithExistingCommentMode(ExistingCommentMerge)},
+			want:  "SELECT 1 /*+ SeqScan(t) */ /*key='value'*/",
+		},
+		{
+			name:  "query with inner comment merge",
+			query: "SELECT /*app='first'*/ 1",
+			opts:  []Option{WithAttrPairs("key", "value"), WithExistingCommentMode(ExistingCommentMerge)},
+			want:  "SELECT /*app='first'*/ 1 /*key='value'*/",
+		},
+		{
+			name:  "query with trailing semicolon",
+			query: "SELECT 1;",
+			opts:  []Option{WithAttrPairs("key", "value")},
+			want:  "SELECT 1 /*key='value'*/;",
+		},
+		{
+			name:  "query with trailing semicolons and whitespace",
+			query: "SELECT 1 ; ;\n",
+			opts:  []Option{WithAttrPairs("key", "value")},
+			want:  "SELECT 1 /*key='value'*/ ; ;\n",
+		},
+		{
+			name:  "query with line comment after semicolon",
+			query: "SELECT 1; -- c",
+			opts:  []Option{WithAttrPairs("k", "v")},
+			want:  "SELECT 1 /*k='v'*/; -- c",
+		},
+		{
+			name:  "query with semicolon in string literal",
+			query: "SELECT ';'",
+			opts:  []Option{WithAttrPairs("key", "value")},
+			want:  "SELECT ';' /*key='value'*/",
+		},
+		{
+			name:  "query with semicolon in line comment append",
+			query: "SELECT 1 -- comment;",
+			opts:  []Option{WithAttrPairs("key", "value"), WithExistingCommentMode(ExistingCommentAppend)},
+			want:  "SELECT 1 -- comment;\n/*key='value'*/",
+		},
+		{
+			name:  "query with sqlcommenter comment and semicolon merge",
+			query: "SELECT 1 /*app='first'*/;",
+			opts:  []Option{WithAttrPairs("key", "value"), WithExistingCommentMode(ExistingCommentMerge)},
+			want:  "SELECT 1 /*app='first',key='value'*/;",
+		},
+		{
+			name:  "query with volatile keys",
+			query: "SELECT 1",
+			opts:  []Option{WithAttrPairs("key", "value", "request", "22"), WithVolatileKeys("request")},
+			want:  "SELECT 1 /*key='value'*/",
+		},
+		{
+			name:  "query with only volatile keys",
+			query: "SELECT 1",
+			opts:  []Option{WithAttrPairs("request", "22"), WithVolatileKeys("request")},
+			want:  "SELECT 1",
+		},
+		{
+			name:  "query with prefix position",
+			query: "SELECT 1;",
+			opts:  []Option{WithAttrPairs("key", "value"), WithPositionMode(PositionPrefix)},
+			want:  "/*key='value'*/ SELECT 1;",
+		},
+		{
+			name:  "query with sqlcommenter comment prefix position merge",
+			query: "/*app='first'*/ SELECT 1",
+			opts:  []Option{WithAttrPairs("key", "value"), WithPositionMode(PositionPrefix), WithExistingCommentMode(ExistingCommentMerge)},
+			want:  "/*app='first',key='value'*/ SELECT 1",
+		},
+		{
+			name:  "query with trailing sqlcommenter comment prefix position merge",
+			query: "SELECT 1 /*app='first'*/",
+			opts:  []Option{WithAttrPairs("key", "value"), WithPositionMode(PositionPrefix), WithExistingCommentMode(ExistingCommentMerge)},
+			want:  "/*key='value'*/ SELECT 1 /*app='first'*/",
+		},
 	}
 
 	for _, cs := range cases {
@@ -55,6 +183,122 @@ func TestComment(t *testing.T) {
 	}
 }
 
+func TestCommentMergeMode(t *testing.T) {
+	cases := []struct {
+		name          string
+		opts          []Option
+		ctxAttrs      Attrs
+		want          string
+		wantConflicts []string
+	}{
+		{
+			name: "last wins",
+			opts: []Option{
+				WithAttrPairs("app", "first", "key", "value"),
+				WithAttrPairs("app", "second"),
+				WithAttrPairs("app", "third"),
+			},
+			want:          "SELECT 1 /*app='third',key='value'*/",
+			wantConflicts: []string{"app:first:second", "app:second:third"},
+		},
+		{
+			name: "first wins",
+			opts: []Option{
+				WithMergeMode(MergeFirstWins),
+				WithAttrPairs("app", "first", "key", "value"),
+				WithAttrPairs("app", "second"),
+				WithAttrPairs("app", "third"),
+			},
+			want:          "SELECT 1 /*app='first',key='value'*/",
+			wantConflicts: []string{"app:first:second", "app:first:third"},
+		},
+		{
+			name: "drop conflicts",
+			opts: []Option{
+				WithMergeMode(MergeDropConflicts),
+				WithAttrPairs("app", "first", "key", "value"),
+				WithAttrPairs("app", "second"),
+				WithAttrPairs("app", "third"),
+			},
+			want:          "SELECT 1 /*key='value'*/",
+			wantConflicts: []string{"app:first:second"},
+		},
+		{
+			name: "same values do not conflict",
+			opts: []Option{
+				WithMergeMode(MergeDropConflicts),
+				WithAttrPairs("app", "first"),
+				WithAttrPairs("app", "first"),
+			},
+			want: "SELECT 1 /*app='first'*/",
+		},
+		{
+			name: "pinned keys",
+			opts: []Option{
+				WithPinnedKeys("app"),
+				WithAttrPairs("app", "first", "key", "value"),
+				WithAttrPairs("app", "second", "key", "value 2"),
+			},
+			want:          "SELECT 1 /*app='first',key='value%202'*/",
+			wantConflicts: []string{"app:first:second", "key:value:value 2"},
+		},
+		{
+			name: "pinned keys with drop conflicts",
+			opts: []Option{
+				WithMergeMode(MergeDropConflicts),
+				WithPinnedKeys("app"),
+				WithAttrPairs("app", "first"),
+				WithAttrPairs("app", "second"),
+			},
+			want:          "SELECT 1 /*app='first'*/",
+			wantConflicts: []string{"app:first:second"},
+		},
+		{
+			name: "context attrs merged last",
+			opts: []Option{
+				WithMergeMode(MergeFirstWins),
+				WithAttrPairs("app", "first"),
+			},
+			ctxAttrs:      Attrs{"app": "context", "key": "value"},
+			want:          "SELECT 1 /*app='first',key='value'*/",
+			wantConflicts: []string{"app:first:context"},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			var conflicts []string
+			opts := append(cs.opts, WithConflictFunc(func(key string, current string, next string) {
+				conflicts = append(conflicts, key+":"+current+":"+next)
+			}))
+
+			ctx := context.Background()
+			if cs.ctxAttrs != nil {
+				ctx = ContextWithAttrs(ctx, cs.ctxAttrs)
+			}
+
+			got := Comment(ctx, "SELECT 1", opts...)
+			if want := cs.want; want != got {
+				t.Errorf("got '%v', want '%v'", got, want)
+			}
+			sort.Strings(conflicts)
+			if !reflect.DeepEqual(conflicts, cs.wantConflicts) {
+				t.Errorf("got '%v', want '%v'", conflicts, cs.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestCommenter(t *testing.T) {
+	cmt := NewCommenter(WithAttrPairs("key", "value"))
+	ctx := ContextWithAttrs(context.Background(), Attrs{"request": "22"})
+
+	got := cmt.Comment(ctx, "SELECT 1;")
+	if want := "SELECT 1 /*key='value',request='22'*/;"; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
 func TestCommentConcurrent(t *testing.T) {
 	var wg sync.WaitGroup
 
@@ -92,3 +336,87 @@ func BenchmarkComment(b *testing.B) {
 		cmt.comment(ctx, "SELECT * FROM my_table WHERE column IS NOT NULL")
 	}
 }
+
+func BenchmarkCommentProviders(b *testing.B) {
+	ctx := ContextWithAttrs(context.Background(), Attrs{"request": "22"})
+	cmt := newCommenter(
+		WithAttrs(map[string]string{
+			"key":  "value",
+			"2key": "value 33",
+		}),
+		WithAttrPairs("application", "hello-app", "key", "other"),
+		WithAttrFunc(func(ctx context.Context) Attrs {
+			return nil
+		}),
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cmt.comment(ctx, "SELECT * FROM my_table WHERE column IS NOT NULL")
+	}
+}
+
+func BenchmarkCommentProvidersFiltered(b *testing.B) {
+	ctx := context.Background()
+	cmt := newCommenter(
+		WithAttrs(map[string]string{
+			"key":  "value",
+			"2key": "value 33",
+		}),
+		WithAttrPairs("application", "hello-app", "secret", "token"),
+		WithDeniedKeys("secret"),
+		WithMaxValueLength(5),
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cmt.comment(ctx, "SELECT * FROM my_table WHERE column IS NOT NULL")
+	}
+}
+
+func BenchmarkCommentStatic(b *testing.B) {
+	ctx := ContextWithAttrs(context.Background(), Attrs{"request": "22"})
+	cmt := newCommenter(
+		WithAttrs(map[string]string{
+			"application": "hello app",
+			"version":     "v1.2.3+build/45",
+			"region":      "eu-west-1",
+			"host":        "api-7f9c/pod 3",
+		}),
+		WithAttrPairs("db_driver", "pgx/v5", "framework", "net/http", "team", "growth & billing"),
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cmt.comment(ctx, "SELECT * FROM my_table WHERE column IS NOT NULL")
+	}
+}
+
+func BenchmarkCommentDynamic(b *testing.B) {
+	ctx := ContextWithAttrs(context.Background(), Attrs{"request": "22"})
+	attrs := map[string]string{
+		"application": "hello app",
+		"version":     "v1.2.3+build/45",
+		"region":      "eu-west-1",
+		"host":        "api-7f9c/pod 3",
+		"db_driver":   "pgx/v5",
+		"framework":   "net/http",
+		"team":        "growth & billing",
+	}
+	cmt := newCommenter(WithAttrFunc(func(ctx context.Context) Attrs {
+		return attrs
+	}))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cmt.comment(ctx, "SELECT * FROM my_table WHERE column IS NOT NULL")
+	}
+}
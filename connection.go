@@ -3,10 +3,10 @@ package sqlcommenter
 import (
 	"context"
 	"database/sql/driver"
+	"errors"
 )
 
 var (
-	_ driver.Pinger             = (*connection)(nil)
 	_ driver.Execer             = (*connection)(nil) // nolint:staticcheck
 	_ driver.ExecerContext      = (*connection)(nil)
 	_ driver.Queryer            = (*connection)(nil) // nolint:staticcheck
@@ -14,36 +14,152 @@ var (
 	_ driver.Conn               = (*connection)(nil)
 	_ driver.ConnPrepareContext = (*connection)(nil)
 	_ driver.ConnBeginTx        = (*connection)(nil)
-	_ driver.SessionResetter    = (*connection)(nil)
 	_ driver.NamedValueChecker  = (*connection)(nil)
 )
 
-func newConn(conn driver.Conn, cmt *commenter) *connection {
-	return &connection{
-		Conn: conn,
-		cmt:  cmt,
+var (
+	// errIsolationLevel is returned when isolation level is requested from driver not supporting driver.ConnBeginTx.
+	errIsolationLevel = errors.New("sqlcommenter: driver does not support non-default isolation level")
+	// errReadOnly is returned when read-only transaction is requested from driver not supporting driver.ConnBeginTx.
+	errReadOnly = errors.New("sqlcommenter: driver does not support read-only transactions")
+)
+
+// newConn wraps conn exposing also optional interfaces implemented by conn
+// which can not be emulated when missing. driver.SessionResetter is always exposed
+// when session statements are configured. Session holds session statements executed on conn.
+func newConn(conn driver.Conn, cmt *commenter, session []string) driver.Conn {
+	c := &connection{
+		Conn:        conn,
+		cmt:         cmt,
+		lastSession: session,
+	}
+
+	pinger, isPinger := conn.(driver.Pinger)
+	resetter, isResetter := conn.(driver.SessionResetter)
+	if len(cmt.sessionStmts) > 0 {
+		resetter, isResetter = &sessionResetter{conn: c}, true
+	}
+	validator, isValidator := conn.(driver.Validator)
+
+	switch {
+	case isPinger && isResetter && isValidator:
+		return &struct {
+			*connection
+			driver.Pinger
+			driver.SessionResetter
+			driver.Validator
+		}{c, pinger, resetter, validator}
+	case isPinger && isResetter:
+		return &struct {
+			*connection
+			driver.Pinger
+			driver.SessionResetter
+		}{c, pinger, resetter}
+	case isPinger && isValidator:
+		return &struct {
+			*connection
+			driver.Pinger
+			driver.Validator
+		}{c, pinger, validator}
+	case isResetter && isValidator:
+		return &struct {
+			*connection
+			driver.SessionResetter
+			driver.Validator
+		}{c, resetter, validator}
+	case isPinger:
+		return &struct {
+			*connection
+			driver.Pinger
+		}{c, pinger}
+	case isResetter:
+		return &struct {
+			*connection
+			driver.SessionResetter
+		}{c, resetter}
+	case isValidator:
+		return &struct {
+			*connection
+			driver.Validator
+		}{c, validator}
+	default:
+		return c
 	}
 }
 
 type connection struct {
 	driver.Conn
 	cmt *commenter
+	// lastVolatile is the last volatile statement executed on connection.
+	lastVolatile string
+	// lastSession are session statements executed on connection.
+	lastSession []string
+	// inTx reports whether transaction started with volatile statement configured is in progress.
+	inTx bool
+}
+
+func (c *connection) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
 }
 
 func (c *connection) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
-	preparer, ok := c.Conn.(driver.ConnPrepareContext)
-	if !ok {
-		return nil, driver.ErrSkip
+	commented := query
+	if c.cmt.prepareMode == PrepareComment {
+		var err error
+		if commented, err = c.withComment(ctx, query); err != nil {
+			return nil, err
+		}
 	}
-	return preparer.PrepareContext(ctx, query)
+
+	var st driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		st, err = preparer.PrepareContext(ctx, commented)
+	} else {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		st, err = c.Conn.Prepare(commented)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newStmt(st, query, c), nil
 }
 
 func (c *connection) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
-	beginTx, ok := c.Conn.(driver.ConnBeginTx)
-	if !ok {
-		return nil, driver.ErrSkip
+	tx, err := c.beginTx(ctx, opts)
+	if err != nil || c.cmt.volatileStmt == nil {
+		return tx, err
+	}
+	c.inTx = true
+	return &volatileTx{Tx: tx, conn: c}, nil
+}
+
+func (c *connection) beginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c.cmt.txMode == TxStatements {
+		if execer, ok := c.Conn.(driver.ExecerContext); ok {
+			if tx, ok, err := beginCommentTx(ctx, execer, c.cmt, opts); ok {
+				return tx, err
+			}
+		}
+	}
+
+	if beginTx, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginTx.BeginTx(ctx, opts)
+	}
+
+	// database/sql does not fall back to driver.Conn.Begin once driver.ConnBeginTx is implemented
+	if opts.Isolation != driver.IsolationLevel(0) {
+		return nil, errIsolationLevel
+	}
+	if opts.ReadOnly {
+		return nil, errReadOnly
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	return beginTx.BeginTx(ctx, opts)
+	return c.Conn.Begin() // nolint:staticcheck
 }
 
 func (c *connection) Query(query string, args []driver.Value) (driver.Rows, error) {
@@ -51,7 +167,11 @@ func (c *connection) Query(query string, args []driver.Value) (driver.Rows, erro
 	if !ok {
 		return nil, driver.ErrSkip
 	}
-	return queryer.Query(c.withComment(context.Background(), query), args)
+	query, err := c.withComment(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	return queryer.Query(query, args)
 }
 
 func (c *connection) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
@@ -59,7 +179,11 @@ func (c *connection) QueryContext(ctx context.Context, query string, args []driv
 	if !ok {
 		return nil, driver.ErrSkip
 	}
-	return queryer.QueryContext(ctx, c.withComment(ctx, query), args)
+	query, err := c.withComment(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return queryer.QueryContext(ctx, query, args)
 }
 
 func (c *connection) Exec(query string, args []driver.Value) (driver.Result, error) {
@@ -67,7 +191,11 @@ func (c *connection) Exec(query string, args []driver.Value) (driver.Result, err
 	if !ok {
 		return nil, driver.ErrSkip
 	}
-	return execer.Exec(c.withComment(context.Background(), query), args)
+	query, err := c.withComment(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	return execer.Exec(query, args)
 }
 
 func (c *connection) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
@@ -75,15 +203,11 @@ func (c *connection) ExecContext(ctx context.Context, query string, args []drive
 	if !ok {
 		return nil, driver.ErrSkip
 	}
-	return execer.ExecContext(ctx, c.withComment(ctx, query), args)
-}
-
-func (c *connection) Ping(ctx context.Context) error {
-	pinger, ok := c.Conn.(driver.Pinger)
-	if !ok {
-		return driver.ErrSkip
+	query, err := c.withComment(ctx, query)
+	if err != nil {
+		return nil, err
 	}
-	return pinger.Ping(ctx)
+	return execer.ExecContext(ctx, query, args)
 }
 
 func (c *connection) CheckNamedValue(value *driver.NamedValue) error {
@@ -94,14 +218,47 @@ func (c *connection) CheckNamedValue(value *driver.NamedValue) error {
 	return checker.CheckNamedValue(value)
 }
 
-func (c *connection) ResetSession(ctx context.Context) error {
-	resetter, ok := c.Conn.(driver.SessionResetter)
-	if !ok {
-		return driver.ErrSkip
+// Unwrap returns wrapped driver.Conn.
+func (c *connection) Unwrap() driver.Conn {
+	return c.Conn
+}
+
+func (c *connection) withComment(ctx context.Context, query string) (string, error) {
+	commented, volatile := c.cmt.commentSplit(ctx, query)
+	if c.inTx && isRollback(query) {
+		// rolling back to savepoint may revert volatile statement
+		c.lastVolatile = ""
+		return commented, nil
 	}
-	return resetter.ResetSession(ctx)
+	if err := c.sendVolatile(ctx, volatile); err != nil {
+		return "", err
+	}
+	return commented, nil
 }
 
-func (c *connection) withComment(ctx context.Context, query string) string {
-	return c.cmt.comment(ctx, query)
+// sendVolatile executes volatile statement built from attrs, unless the same statement
+// was already executed on connection. Failed statement is ignored inside transaction,
+// as it fails in aborted transaction and would prevent recovering it.
+func (c *connection) sendVolatile(ctx context.Context, attrs Attrs) error {
+	if c.cmt.volatileStmt == nil || (len(attrs) == 0 && c.lastVolatile == "") {
+		return nil
+	}
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil
+	}
+
+	stmt := c.cmt.volatileStmt(attrs)
+	if stmt == c.lastVolatile {
+		return nil
+	}
+	if _, err := execer.ExecContext(ctx, stmt, nil); err != nil {
+		c.lastVolatile = ""
+		if c.inTx {
+			return nil
+		}
+		return err
+	}
+	c.lastVolatile = stmt
+	return nil
 }
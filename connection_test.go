@@ -0,0 +1,239 @@
+package sqlcommenter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestNewConnOptionalInterfaces(t *testing.T) {
+	cases := []struct {
+		name          string
+		conn          driver.Conn
+		wantPinger    bool
+		wantResetter  bool
+		wantValidator bool
+	}{
+		{
+			name: "none",
+			conn: &mockConn{},
+		},
+		{
+			name: "pinger",
+			conn: &struct {
+				*mockConn
+				mockPinger
+			}{&mockConn{}, mockPinger{}},
+			wantPinger: true,
+		},
+		{
+			name: "resetter",
+			conn: &struct {
+				*mockConn
+				mockResetter
+			}{&mockConn{}, mockResetter{}},
+			wantResetter: true,
+		},
+		{
+			name: "validator",
+			conn: &struct {
+				*mockConn
+				mockValidator
+			}{&mockConn{}, mockValidator{}},
+			wantValidator: true,
+		},
+		{
+			name: "pinger and resetter",
+			conn: &struct {
+				*mockConn
+				mockPinger
+				mockResetter
+			}{&mockConn{}, mockPinger{}, mockResetter{}},
+			wantPinger:   true,
+			wantResetter: true,
+		},
+		{
+			name: "pinger and validator",
+			conn: &struct {
+				*mockConn
+				mockPinger
+				mockValidator
+			}{&mockConn{}, mockPinger{}, mockValidator{}},
+			wantPinger:    true,
+			wantValidator: true,
+		},
+		{
+			name: "resetter and validator",
+			conn: &struct {
+				*mockConn
+				mockResetter
+				mockValidator
+			}{&mockConn{}, mockResetter{}, mockValidator{}},
+			wantResetter:  true,
+			wantValidator: true,
+		},
+		{
+			name: "all",
+			conn: &struct {
+				*mockConn
+				mockPinger
+				mockResetter
+				mockValidator
+			}{&mockConn{}, mockPinger{}, mockResetter{}, mockValidator{}},
+			wantPinger:    true,
+			wantResetter:  true,
+			wantValidator: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			conn := newConn(cs.conn, newCommenter(), nil)
+
+			pinger, ok := conn.(driver.Pinger)
+			if ok != cs.wantPinger {
+				t.Errorf("got pinger '%v', want '%v'", ok, cs.wantPinger)
+			}
+			if ok {
+				assertNoError(t, pinger.Ping(context.Background()))
+			}
+
+			resetter, ok := conn.(driver.SessionResetter)
+			if ok != cs.wantResetter {
+				t.Errorf("got resetter '%v', want '%v'", ok, cs.wantResetter)
+			}
+			if ok {
+				assertNoError(t, resetter.ResetSession(context.Background()))
+			}
+
+			validator, ok := conn.(driver.Validator)
+			if ok != cs.wantValidator {
+				t.Errorf("got validator '%v', want '%v'", ok, cs.wantValidator)
+			}
+			if ok && !validator.IsValid() {
+				t.Error("got invalid conn, want valid")
+			}
+
+			for _, iface := range []bool{
+				implements[driver.ExecerContext](conn),
+				implements[driver.QueryerContext](conn),
+				implements[driver.ConnPrepareContext](conn),
+				implements[driver.ConnBeginTx](conn),
+				implements[driver.NamedValueChecker](conn),
+			} {
+				if !iface {
+					t.Errorf("got '%T' missing required interface", conn)
+				}
+			}
+
+			unwrapper, ok := conn.(interface{ Unwrap() driver.Conn })
+			if !ok {
+				t.Fatalf("got '%T' without Unwrap", conn)
+			}
+			if got := unwrapper.Unwrap(); got != cs.conn {
+				t.Errorf("got '%v', want '%v'", got, cs.conn)
+			}
+		})
+	}
+}
+
+func TestNewConnSessionResetter(t *testing.T) {
+	orig := &mockConn{}
+	cmt := newCommenter(WithSessionStatement(SetStatement("application_name"), AttrProviderFunc(func(ctx context.Context) Attrs {
+		return AttrPairs("application", "hello")
+	})))
+
+	resetter, ok := newConn(orig, cmt, nil).(driver.SessionResetter)
+	if !ok {
+		t.Fatal("got conn without driver.SessionResetter")
+	}
+	assertNoError(t, resetter.ResetSession(context.Background()))
+	orig.assertExecContext(t, "SET application_name = 'application=''hello'''", 0)
+}
+
+func TestConnectionBeginLegacy(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    *sql.TxOptions
+		wantErr error
+	}{
+		{
+			name: "default options",
+		},
+		{
+			name:    "isolation level",
+			opts:    &sql.TxOptions{Isolation: sql.LevelSerializable},
+			wantErr: errIsolationLevel,
+		},
+		{
+			name:    "read only",
+			opts:    &sql.TxOptions{ReadOnly: true},
+			wantErr: errReadOnly,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			db := sql.OpenDB(WrapConnector(&mockLegacyConnector{conn: &mockLegacyConn{}}))
+			defer db.Close()
+
+			tx, err := db.BeginTx(context.Background(), cs.opts)
+			if !errors.Is(err, cs.wantErr) {
+				t.Fatalf("got '%v', want '%v'", err, cs.wantErr)
+			}
+			if err == nil {
+				assertNoError(t, tx.Commit())
+			}
+		})
+	}
+}
+
+func TestConnectionRaw(t *testing.T) {
+	orig := &mockConn{}
+	db := sql.OpenDB(WrapConnector(&mockConnector{
+		drv:  &mockDriverContext{conn: orig},
+		conn: orig,
+	}))
+	defer db.Close()
+
+	conn, err := db.Conn(context.Background())
+	assertNoError(t, err)
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		unwrapper, ok := driverConn.(interface{ Unwrap() driver.Conn })
+		if !ok {
+			t.Fatalf("got '%T' without Unwrap", driverConn)
+		}
+		if got := unwrapper.Unwrap(); got != orig {
+			t.Errorf("got '%v', want '%v'", got, orig)
+		}
+		return nil
+	})
+	assertNoError(t, err)
+}
+
+func implements[T any](v any) bool {
+	_, ok := v.(T)
+	return ok
+}
+
+type mockPinger struct{}
+
+func (mockPinger) Ping(ctx context.Context) error {
+	return nil
+}
+
+type mockResetter struct{}
+
+func (mockResetter) ResetSession(ctx context.Context) error {
+	return nil
+}
+
+type mockValidator struct{}
+
+func (mockValidator) IsValid() bool {
+	return true
+}
@@ -0,0 +1,55 @@
+package sqlcommenter
+
+import (
+	"context"
+)
+
+type attrsContextKey struct{}
+
+type skipContextKey struct{}
+
+type optionsContextKey struct{}
+
+// ContextWithAttrs returns copy of ctx carrying attrs merged with Attrs already stored in ctx.
+// Attrs stored by inner calls take precedence over Attrs stored by outer calls.
+func ContextWithAttrs(ctx context.Context, attrs Attrs) context.Context {
+	parent := AttrsFromContext(ctx)
+	merged := make(Attrs, len(parent)+len(attrs))
+	merged.Update(parent)
+	merged.Update(attrs)
+	return context.WithValue(ctx, attrsContextKey{}, merged)
+}
+
+// AttrsFromContext returns Attrs stored in ctx by ContextWithAttrs.
+// Returned Attrs must not be modified.
+func AttrsFromContext(ctx context.Context) Attrs {
+	attrs, _ := ctx.Value(attrsContextKey{}).(Attrs)
+	return attrs
+}
+
+// WithoutComment returns copy of ctx which disables commenting of queries.
+func WithoutComment(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipContextKey{}, true)
+}
+
+// WithCommentOptions returns copy of ctx carrying opts which are applied on top of options
+// configured in WrapDriver, WrapConnector or NewCommenter for queries using ctx.
+// Options controlling wrapped connections, WithPrepareMode, WithTxMode, WithVolatileStatement
+// and WithSessionStatement, are read from options of WrapDriver or WrapConnector and are ignored in ctx.
+func WithCommentOptions(ctx context.Context, opts ...Option) context.Context {
+	parent := optionsFromContext(ctx)
+	merged := make([]Option, 0, len(parent)+len(opts))
+	merged = append(merged, parent...)
+	merged = append(merged, opts...)
+	return context.WithValue(ctx, optionsContextKey{}, merged)
+}
+
+func skipFromContext(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipContextKey{}).(bool)
+	return skip
+}
+
+func optionsFromContext(ctx context.Context) []Option {
+	opts, _ := ctx.Value(optionsContextKey{}).([]Option)
+	return opts
+}
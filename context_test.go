@@ -0,0 +1,65 @@
+package sqlcommenter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestContextWithAttrs(t *testing.T) {
+	ctx := context.Background()
+	if got := AttrsFromContext(ctx); got != nil {
+		t.Errorf("got '%v', want '%v'", got, nil)
+	}
+
+	outer := Attrs{"key": "outer", "outer": "value"}
+	ctx = ContextWithAttrs(ctx, outer)
+	ctx = ContextWithAttrs(ctx, Attrs{"key": "inner", "inner": "value"})
+
+	want := Attrs{"key": "inner", "outer": "value", "inner": "value"}
+	if got := AttrsFromContext(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+
+	outer["key"] = "changed"
+	if got := AttrsFromContext(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func TestCommentContextAttrs(t *testing.T) {
+	cases := []struct {
+		name  string
+		attrs Attrs
+		opts  []Option
+		want  string
+	}{
+		{
+			name:  "context attrs without options",
+			attrs: Attrs{"key": "value"},
+			want:  "SELECT 1 /*key='value'*/",
+		},
+		{
+			name:  "context attrs with provider",
+			attrs: Attrs{"key": "value"},
+			opts:  []Option{WithAttrPairs("app", "hello")},
+			want:  "SELECT 1 /*app='hello',key='value'*/",
+		},
+		{
+			name:  "context attrs override provider",
+			attrs: Attrs{"key": "context"},
+			opts:  []Option{WithAttrPairs("key", "provider")},
+			want:  "SELECT 1 /*key='context'*/",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			ctx := ContextWithAttrs(context.Background(), cs.attrs)
+			got := Comment(ctx, "SELECT 1", cs.opts...)
+			if want := cs.want; want != got {
+				t.Fatalf("got '%v', want '%v'", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package sqlcommenter
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+)
+
+// Dialect controls how are comments, string literals and quoted identifiers recognized in queries.
+// It determines whether query already contains a comment, where is the comment placed
+// and which comment is merged with ExistingCommentMerge.
+// Attrs are escaped the same way in all dialects, since escaped comment contains
+// no quotes, backslashes, question marks or comment delimiters.
+type Dialect int
+
+const (
+	// DialectGeneric recognizes 'text' strings, "name" identifiers, $$text$$ dollar-quoted bodies,
+	// -- line comments and non-nested /* */ block comments. Hints are treated as comments.
+	DialectGeneric Dialect = iota
+	// DialectPostgres extends DialectGeneric with E'text' strings, nested block comments
+	// and /*+ */ hints of pg_hint_plan.
+	DialectPostgres
+	// DialectMySQL recognizes backslash escapes in strings, `name` identifiers, # comments,
+	// -- comments followed by whitespace, /*+ */ hints and /*! */ executable comments.
+	DialectMySQL
+	// DialectSQLite recognizes 'text' strings, "name", `name` and [name] identifiers.
+	DialectSQLite
+	// DialectSQLServer recognizes 'text' strings, "name" and [name] identifiers and nested block comments.
+	DialectSQLServer
+	// DialectOracle recognizes q'[text]' strings and /*+ */ hints.
+	DialectOracle
+)
+
+var dialectNames = map[Dialect]string{
+	DialectGeneric:   "generic",
+	DialectPostgres:  "postgres",
+	DialectMySQL:     "mysql",
+	DialectSQLite:    "sqlite",
+	DialectSQLServer: "sqlserver",
+	DialectOracle:    "oracle",
+}
+
+// String returns name of dialect.
+func (d Dialect) String() string {
+	if name, ok := dialectNames[d]; ok {
+		return name
+	}
+	return dialectNames[DialectGeneric]
+}
+
+// dialectRules describes syntax recognized by scanner.
+type dialectRules struct {
+	dollarQuotes      bool
+	escapeStrings     bool
+	backslashEscapes  bool
+	qQuotes           bool
+	backticks         bool
+	brackets          bool
+	hashComments      bool
+	dashSpaceComments bool
+	nestedComments    bool
+	hints             []string
+}
+
+var rulesByDialect = map[Dialect]dialectRules{
+	DialectGeneric: {
+		dollarQuotes: true,
+	},
+	DialectPostgres: {
+		dollarQuotes:   true,
+		escapeStrings:  true,
+		nestedComments: true,
+		hints:          []string{"/*+"},
+	},
+	DialectMySQL: {
+		backslashEscapes:  true,
+		backticks:         true,
+		hashComments:      true,
+		dashSpaceComments: true,
+		hints:             []string{"/*+", "/*!"},
+	},
+	DialectSQLite: {
+		backticks: true,
+		brackets:  true,
+	},
+	DialectSQLServer: {
+		brackets:       true,
+		nestedComments: true,
+	},
+	DialectOracle: {
+		qQuotes: true,
+		hints:   []string{"/*+"},
+	},
+}
+
+func (d Dialect) rules() dialectRules {
+	if rules, ok := rulesByDialect[d]; ok {
+		return rules
+	}
+	return rulesByDialect[DialectGeneric]
+}
+
+// driverDialects maps parts of driver type names to dialects, checked in order.
+var driverDialects = []struct {
+	name    string
+	dialect Dialect
+}{
+	{name: "mssql", dialect: DialectSQLServer},
+	{name: "sqlserver", dialect: DialectSQLServer},
+	{name: "mysql", dialect: DialectMySQL},
+	{name: "sqlite", dialect: DialectSQLite},
+	{name: "postgres", dialect: DialectPostgres},
+	{name: "pgx", dialect: DialectPostgres},
+	{name: "/pq.", dialect: DialectPostgres},
+	{name: "oracle", dialect: DialectOracle},
+	{name: "godror", dialect: DialectOracle},
+	{name: "go-ora", dialect: DialectOracle},
+}
+
+// DetectDialect returns Dialect of drv detected from its package path and type name.
+// It returns DialectGeneric for unknown drivers.
+func DetectDialect(drv driver.Driver) Dialect {
+	if d, ok := drv.(*commentDriver); ok {
+		return d.cmt.dialect
+	}
+
+	typ := reflect.TypeOf(drv)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil {
+		return DialectGeneric
+	}
+
+	name := strings.ToLower(typ.PkgPath() + "." + typ.Name())
+	for _, dd := range driverDialects {
+		if strings.Contains(name, dd.name) {
+			return dd.dialect
+		}
+	}
+	return DialectGeneric
+}
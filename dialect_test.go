@@ -0,0 +1,193 @@
+package sqlcommenter
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestDialectHasComment(t *testing.T) {
+	cases := []struct {
+		dialect Dialect
+		query   string
+		want    bool
+	}{
+		{dialect: DialectGeneric, query: "SELECT /*+ SeqScan(t) */ * FROM t", want: true},
+		{dialect: DialectGeneric, query: "SELECT 'a\\' /* comment */", want: true},
+		{dialect: DialectGeneric, query: "SELECT `a--b` FROM t", want: true},
+		{dialect: DialectGeneric, query: "SELECT 1 # comment", want: false},
+
+		{dialect: DialectPostgres, query: "/*+ SeqScan(t) */ SELECT * FROM t", want: false},
+		{dialect: DialectPostgres, query: "SELECT E'it\\'s /* not */ a comment'", want: false},
+		{dialect: DialectPostgres, query: "SELECT 'a\\' /* comment */", want: true},
+		{dialect: DialectPostgres, query: "SELECT $tag$ -- not $tag$", want: false},
+		{dialect: DialectPostgres, query: "SELECT 1 /* outer /* inner */ still */", want: true},
+
+		{dialect: DialectMySQL, query: "SELECT /*+ BKA(t) */ * FROM t", want: false},
+		{dialect: DialectMySQL, query: "SELECT /*!40001 SQL_NO_CACHE */ * FROM t", want: false},
+		{dialect: DialectMySQL, query: "SELECT 'it\\' /* not */ a comment'", want: false},
+		{dialect: DialectMySQL, query: "SELECT \"it\\\" -- not\" FROM t", want: false},
+		{dialect: DialectMySQL, query: "SELECT `a--b`, `c/*d` FROM t", want: false},
+		{dialect: DialectMySQL, query: "SELECT 1 # comment", want: true},
+		{dialect: DialectMySQL, query: "SELECT 1--1", want: false},
+		{dialect: DialectMySQL, query: "SELECT 1 -- comment", want: true},
+		{dialect: DialectMySQL, query: "SELECT $$ -- comment $$", want: true},
+
+		{dialect: DialectSQLite, query: "SELECT [a--b], `c/*d` FROM t", want: false},
+		{dialect: DialectSQLite, query: "SELECT /*+ hint */ 1", want: true},
+
+		{dialect: DialectSQLServer, query: "SELECT [it's /* not */ a comment] FROM t", want: false},
+		{dialect: DialectSQLServer, query: "SELECT 1 -- comment", want: true},
+
+		{dialect: DialectOracle, query: "SELECT /*+ FULL(t) */ * FROM t", want: false},
+		{dialect: DialectOracle, query: "SELECT q'[it's /* not */ a comment]' FROM dual", want: false},
+		{dialect: DialectOracle, query: "SELECT nq'{it's -- not}' FROM dual", want: false},
+		{dialect: DialectOracle, query: "SELECT q'!it's!' /* comment */ FROM dual", want: true},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.dialect.String()+" "+cs.query, func(t *testing.T) {
+			if got := cs.dialect.hasComment(cs.query); cs.want != got {
+				t.Errorf("got '%v', want '%v'", got, cs.want)
+			}
+		})
+	}
+}
+
+func TestDialectComment(t *testing.T) {
+	cases := []struct {
+		dialect Dialect
+		query   string
+		opts    []Option
+		want    string
+	}{
+		{
+			dialect: DialectGeneric,
+			query:   "/*+ SeqScan(t) */ SELECT * FROM t",
+			opts:    []Option{WithExistingCommentMode(ExistingCommentAppend), WithPositionMode(PositionPrefix)},
+			want:    "/*key='value'*/ /*+ SeqScan(t) */ SELECT * FROM t",
+		},
+		{
+			dialect: DialectPostgres,
+			query:   "/*+ SeqScan(t) */ SELECT * FROM t",
+			opts:    []Option{WithPositionMode(PositionPrefix)},
+			want:    "/*+ SeqScan(t) */ /*key='value'*/ SELECT * FROM t",
+		},
+		{
+			dialect: DialectPostgres,
+			query:   "/*+ SeqScan(t) */ /*other='1'*/ SELECT * FROM t",
+			opts:    []Option{WithExistingCommentMode(ExistingCommentMerge), WithPositionMode(PositionPrefix)},
+			want:    "/*+ SeqScan(t) */ /*key='value',other='1'*/ SELECT * FROM t",
+		},
+		{
+			dialect: DialectPostgres,
+			query:   "SELECT 1 /* outer /* inner; */ */;",
+			opts:    []Option{WithExistingCommentMode(ExistingCommentAppend)},
+			want:    "SELECT 1 /* outer /* inner; */ */ /*key='value'*/;",
+		},
+		{
+			dialect: DialectMySQL,
+			query:   "SELECT /*+ BKA(t) */ * FROM t;",
+			want:    "SELECT /*+ BKA(t) */ * FROM t /*key='value'*/;",
+		},
+		{
+			dialect: DialectMySQL,
+			query:   "SELECT * FROM t # note",
+			opts:    []Option{WithExistingCommentMode(ExistingCommentAppend)},
+			want:    "SELECT * FROM t # note\n/*key='value'*/",
+		},
+		{
+			dialect: DialectMySQL,
+			query:   "SELECT * FROM t /*!40001 SQL_NO_CACHE */",
+			opts:    []Option{WithExistingCommentMode(ExistingCommentMerge)},
+			want:    "SELECT * FROM t /*!40001 SQL_NO_CACHE */ /*key='value'*/",
+		},
+		{
+			dialect: DialectSQLite,
+			query:   "SELECT [a;--] FROM t;",
+			want:    "SELECT [a;--] FROM t /*key='value'*/;",
+		},
+		{
+			dialect: DialectSQLServer,
+			query:   "SELECT [a'b] FROM t -- note",
+			opts:    []Option{WithExistingCommentMode(ExistingCommentAppend)},
+			want:    "SELECT [a'b] FROM t -- note\n/*key='value'*/",
+		},
+		{
+			dialect: DialectOracle,
+			query:   "SELECT /*+ FULL(t) */ q'[it's]' FROM t",
+			want:    "SELECT /*+ FULL(t) */ q'[it's]' FROM t /*key='value'*/",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.dialect.String()+" "+cs.query, func(t *testing.T) {
+			opts := append([]Option{WithDialect(cs.dialect), WithAttrPairs("key", "value")}, cs.opts...)
+			got := Comment(context.Background(), cs.query, opts...)
+			if want := cs.want; want != got {
+				t.Errorf("got '%v', want '%v'", got, want)
+			}
+		})
+	}
+}
+
+type PostgresDriver struct{ driver.Driver }
+
+type MySQLDriver struct{ driver.Driver }
+
+type SQLiteDriver struct{ driver.Driver }
+
+type MssqlDriver struct{ driver.Driver }
+
+type OracleDriver struct{ driver.Driver }
+
+func TestDetectDialect(t *testing.T) {
+	cases := []struct {
+		drv  driver.Driver
+		want Dialect
+	}{
+		{drv: nil, want: DialectGeneric},
+		{drv: &mockDriver{}, want: DialectGeneric},
+		{drv: &PostgresDriver{}, want: DialectPostgres},
+		{drv: MySQLDriver{}, want: DialectMySQL},
+		{drv: &SQLiteDriver{}, want: DialectSQLite},
+		{drv: &MssqlDriver{}, want: DialectSQLServer},
+		{drv: &OracleDriver{}, want: DialectOracle},
+		{drv: WrapDriver(&mockDriver{}, WithDialect(DialectOracle)), want: DialectOracle},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.want.String(), func(t *testing.T) {
+			if got := DetectDialect(cs.drv); cs.want != got {
+				t.Errorf("got '%v', want '%v'", got, cs.want)
+			}
+		})
+	}
+}
+
+func TestWrapDriverDialect(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		want Dialect
+	}{
+		{
+			name: "detected",
+			want: DialectMySQL,
+		},
+		{
+			name: "configured",
+			opts: []Option{WithDialect(DialectGeneric)},
+			want: DialectGeneric,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			drv := WrapDriver(&MySQLDriver{}, cs.opts...).(*commentDriver)
+			if got := drv.cmt.dialect; cs.want != got {
+				t.Errorf("got '%v', want '%v'", got, cs.want)
+			}
+		})
+	}
+}
@@ -3,27 +3,62 @@ package sqlcommenter
 import (
 	"context"
 	"database/sql/driver"
+	"io"
 )
 
 // WrapDriver wraps sql driver with sqlcommenter support.
+// Attrs stored in context by ContextWithAttrs are always included.
 func WrapDriver(drv driver.Driver, opts ...Option) driver.Driver {
 	return &commentDriver{
 		drv: drv,
-		cmt: newCommenter(opts...),
+		cmt: newDriverCommenter(drv, opts),
 	}
 }
 
+// WrapConnector wraps sql connector with sqlcommenter support.
+// Attrs stored in context by ContextWithAttrs are always included.
+func WrapConnector(ctr driver.Connector, opts ...Option) driver.Connector {
+	drv := &commentDriver{
+		drv: ctr.Driver(),
+		cmt: newDriverCommenter(ctr.Driver(), opts),
+	}
+	return newConnector(ctr, drv)
+}
+
+// newDriverCommenter returns commenter for drv, detecting its dialect unless configured by opts.
+func newDriverCommenter(drv driver.Driver, opts []Option) *commenter {
+	cmt := newCommenter(opts...)
+	if !cmt.dialectSet {
+		cmt.dialect = DetectDialect(drv)
+	}
+	return cmt
+}
+
 type commentDriver struct {
 	drv driver.Driver
 	cmt *commenter
 }
 
 func (d *commentDriver) Open(name string) (driver.Conn, error) {
+	return d.open(context.Background(), name)
+}
+
+func (d *commentDriver) open(ctx context.Context, name string) (driver.Conn, error) {
 	conn, err := d.drv.Open(name)
 	if err != nil {
 		return nil, err
 	}
-	return newConn(conn, d.cmt), nil
+	return d.wrapConn(ctx, conn)
+}
+
+// wrapConn initializes session of conn and wraps it.
+func (d *commentDriver) wrapConn(ctx context.Context, conn driver.Conn) (driver.Conn, error) {
+	session, err := d.cmt.initSession(ctx, conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return newConn(conn, d.cmt, session), nil
 }
 
 func (d *commentDriver) OpenConnector(name string) (driver.Connector, error) {
@@ -56,13 +91,21 @@ func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newConn(conn, c.drv.cmt), nil
+	return c.drv.wrapConn(ctx, conn)
 }
 
 func (c *connector) Driver() driver.Driver {
 	return c.drv
 }
 
+// Close closes wrapped connector when it implements io.Closer, it is called by sql.DB.Close.
+func (c *connector) Close() error {
+	if closer, ok := c.ctr.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 func newDSNConnector(dsn string, drv *commentDriver) *dsnConnector {
 	return &dsnConnector{
 		dsn: dsn,
@@ -75,8 +118,8 @@ type dsnConnector struct {
 	drv *commentDriver
 }
 
-func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
-	return c.drv.Open(c.dsn)
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.drv.open(ctx, c.dsn)
 }
 
 func (c *dsnConnector) Driver() driver.Driver {
@@ -4,17 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"testing"
 )
 
 func TestWrapDriver(t *testing.T) {
 	cases := []struct {
-		name    string
-		makeCtx func() context.Context
-		options []Option
-		perform func(*testing.T, context.Context, *sql.DB)
-		assert  func(*testing.T, *mockConn)
+		name     string
+		makeCtx  func() context.Context
+		options  []Option
+		failExec string
+		perform  func(*testing.T, context.Context, *sql.DB)
+		assert   func(*testing.T, *mockConn)
 	}{
 		{
 			name: "QueryContext no attrs",
@@ -50,6 +52,49 @@ func TestWrapDriver(t *testing.T) {
 				conn.assertQueryContext(t, "SELECT 1 /*user-key='my-key'*/", 0)
 			},
 		},
+		{
+			name:    "QueryContext with existing comment",
+			options: []Option{WithAttrPairs("key", "value")},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				_, _ = db.QueryContext(ctx, "SELECT /*+ SeqScan(t) */ 1")
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertQueryContext(t, "SELECT /*+ SeqScan(t) */ 1 /*key='value'*/", 0)
+			},
+		},
+		{
+			name:    "QueryContext with sqlc header",
+			options: []Option{WithAttrPairs("key", "value")},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				_, _ = db.QueryContext(ctx, "-- name: GetUser :one\nSELECT 1")
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertQueryContext(t, "-- name: GetUser :one\nSELECT 1 /*key='value'*/", 0)
+			},
+		},
+		{
+			name:    "QueryContext with existing comment skip",
+			options: []Option{WithAttrPairs("key", "value"), WithExistingCommentMode(ExistingCommentSkip)},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				_, _ = db.QueryContext(ctx, "SELECT /*+ SeqScan(t) */ 1")
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertQueryContext(t, "SELECT /*+ SeqScan(t) */ 1", 0)
+			},
+		},
+		{
+			name:    "QueryContext attrs from ContextWithAttrs",
+			options: []Option{WithAttrPairs("key", "value")},
+			makeCtx: func() context.Context {
+				return ContextWithAttrs(context.Background(), Attrs{"request": "22"})
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				_, _ = db.QueryContext(ctx, "SELECT 1")
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertQueryContext(t, "SELECT 1 /*key='value',request='22'*/", 0)
+			},
+		},
 		{
 			name: "ExecContext no attrs",
 			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
@@ -126,6 +171,459 @@ func TestWrapDriver(t *testing.T) {
 				conn.assertExecContext(t, "UPDATE users SET name = 'doe' /*user-key='my-key'*/", 1)
 			},
 		},
+		{
+			name:    "PrepareContext QueryContext with attrs",
+			options: []Option{WithAttrPairs("key", "value")},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				stmt, err := db.PrepareContext(ctx, "SELECT 1")
+				assertNoError(t, err)
+				defer stmt.Close()
+
+				_, _ = stmt.QueryContext(ctx)
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertPrepareContext(t, "SELECT 1 /*key='value'*/", 0)
+				conn.assertQueryContext(t, "SELECT 1 /*key='value'*/", 0)
+			},
+		},
+		{
+			name:    "PrepareContext ExecContext with attrs",
+			options: []Option{WithAttrPairs("key", "value")},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				stmt, err := db.PrepareContext(ctx, "UPDATE users SET name = 'joe'")
+				assertNoError(t, err)
+				defer stmt.Close()
+
+				_, _ = stmt.ExecContext(ctx)
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertPrepareContext(t, "UPDATE users SET name = 'joe' /*key='value'*/", 0)
+				conn.assertExecContext(t, "UPDATE users SET name = 'joe' /*key='value'*/", 0)
+			},
+		},
+		{
+			name: "PrepareContext attrs from context",
+			options: []Option{WithAttrFunc(func(ctx context.Context) Attrs {
+				return AttrPairs("user-key", userKeyFromContext(ctx))
+			})},
+			makeCtx: func() context.Context {
+				return withUserKey(context.Background(), "my-key")
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				stmt, err := db.PrepareContext(ctx, "SELECT 1")
+				assertNoError(t, err)
+				defer stmt.Close()
+
+				_, _ = stmt.QueryContext(ctx)
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertPrepareContext(t, "SELECT 1 /*user-key='my-key'*/", 0)
+			},
+		},
+		{
+			name:    "PrepareContext skip",
+			options: []Option{WithAttrPairs("key", "value"), WithPrepareMode(PrepareSkip)},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				stmt, err := db.PrepareContext(ctx, "SELECT 1")
+				assertNoError(t, err)
+				defer stmt.Close()
+
+				_, _ = stmt.QueryContext(ctx)
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertPrepareContext(t, "SELECT 1", 0)
+				conn.assertQueryContext(t, "SELECT 1", 0)
+			},
+		},
+		{
+			name:    "BeginTx driver",
+			options: []Option{WithAttrPairs("key", "value")},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				tx, err := db.BeginTx(ctx, nil)
+				assertNoError(t, err)
+
+				_, _ = tx.ExecContext(ctx, "UPDATE users SET name = 'joe'")
+				assertNoError(t, tx.Commit())
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "UPDATE users SET name = 'joe' /*key='value'*/", 0)
+			},
+		},
+		{
+			name:    "BeginTx statements commit",
+			options: []Option{WithAttrPairs("key", "value"), WithTxMode(TxStatements)},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				tx, err := db.BeginTx(ctx, nil)
+				assertNoError(t, err)
+
+				_, _ = tx.ExecContext(ctx, "UPDATE users SET name = 'joe'")
+				assertNoError(t, tx.Commit())
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "BEGIN /*key='value'*/", 0)
+				conn.assertExecContext(t, "UPDATE users SET name = 'joe' /*key='value'*/", 1)
+				conn.assertExecContext(t, "COMMIT /*key='value'*/", 2)
+			},
+		},
+		{
+			name: "BeginTx statements with options rollback",
+			options: []Option{WithTxMode(TxStatements), WithAttrFunc(func(ctx context.Context) Attrs {
+				return AttrPairs("user-key", userKeyFromContext(ctx))
+			})},
+			makeCtx: func() context.Context {
+				return withUserKey(context.Background(), "my-key")
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true})
+				assertNoError(t, err)
+				assertNoError(t, tx.Rollback())
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "START TRANSACTION ISOLATION LEVEL SERIALIZABLE, READ ONLY /*isolation='Serializable',read_only='true',user-key='my-key'*/", 0)
+				conn.assertExecContext(t, "ROLLBACK /*isolation='Serializable',read_only='true',user-key='my-key'*/", 1)
+			},
+		},
+		{
+			name:    "BeginTx statements mysql read only commit",
+			options: []Option{WithAttrPairs("key", "value"), WithTxMode(TxStatements), WithDialect(DialectMySQL)},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+				assertNoError(t, err)
+				assertNoError(t, tx.Commit())
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "START TRANSACTION READ ONLY /*key='value',read_only='true'*/", 0)
+				conn.assertExecContext(t, "COMMIT /*key='value',read_only='true'*/", 1)
+			},
+		},
+		{
+			name:    "BeginTx statements sqlite rollback",
+			options: []Option{WithAttrPairs("key", "value"), WithTxMode(TxStatements), WithDialect(DialectSQLite)},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				tx, err := db.BeginTx(ctx, nil)
+				assertNoError(t, err)
+				assertNoError(t, tx.Rollback())
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "BEGIN /*key='value'*/", 0)
+				conn.assertExecContext(t, "ROLLBACK /*key='value'*/", 1)
+			},
+		},
+		{
+			name:    "BeginTx statements sqlserver commit",
+			options: []Option{WithAttrPairs("key", "value"), WithTxMode(TxStatements), WithDialect(DialectSQLServer)},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				tx, err := db.BeginTx(ctx, nil)
+				assertNoError(t, err)
+				assertNoError(t, tx.Commit())
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "BEGIN TRANSACTION /*key='value'*/", 0)
+				conn.assertExecContext(t, "COMMIT TRANSACTION /*key='value'*/", 1)
+			},
+		},
+		{
+			name:    "BeginTx statements oracle",
+			options: []Option{WithAttrPairs("key", "value"), WithTxMode(TxStatements), WithDialect(DialectOracle)},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				tx, err := db.BeginTx(ctx, nil)
+				assertNoError(t, err)
+
+				_, _ = tx.ExecContext(ctx, "UPDATE users SET name = 'joe'")
+				assertNoError(t, tx.Commit())
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "UPDATE users SET name = 'joe' /*key='value'*/", 0)
+			},
+		},
+		{
+			name:    "BeginTx statements unsupported isolation level",
+			options: []Option{WithAttrPairs("key", "value"), WithTxMode(TxStatements)},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSnapshot})
+				assertNoError(t, err)
+
+				_, _ = tx.ExecContext(ctx, "UPDATE users SET name = 'joe'")
+				assertNoError(t, tx.Commit())
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "UPDATE users SET name = 'joe' /*key='value'*/", 0)
+			},
+		},
+		{
+			name: "QueryContext volatile keys with statement",
+			options: []Option{
+				WithAttrPairs("key", "value"),
+				WithVolatileKeys("request"),
+				WithVolatileStatement(SetStatement("application_name")),
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				conn, err := db.Conn(ctx)
+				assertNoError(t, err)
+				defer conn.Close()
+
+				first := ContextWithAttrs(ctx, Attrs{"request": "1"})
+				second := ContextWithAttrs(ctx, Attrs{"request": "2"})
+				for i, queryCtx := range []context.Context{first, first, second, ctx, ctx} {
+					rows, err := conn.QueryContext(queryCtx, fmt.Sprintf("SELECT %v", i+1))
+					assertNoError(t, err)
+					_ = rows.Close()
+				}
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "SET application_name = 'request=''1'''", 0)
+				conn.assertExecContext(t, "SET application_name = 'request=''2'''", 1)
+				conn.assertExecContext(t, "SET application_name = ''", 2)
+				if len(conn.execContext) != 3 {
+					t.Errorf("got '%v', want '%v'", len(conn.execContext), 3)
+				}
+				for i := 0; i < 5; i++ {
+					conn.assertQueryContext(t, fmt.Sprintf("SELECT %v /*key='value'*/", i+1), i)
+				}
+			},
+		},
+		{
+			name:    "ExecContext volatile keys without statement",
+			options: []Option{WithAttrPairs("key", "value"), WithVolatileKeys("request")},
+			makeCtx: func() context.Context {
+				return ContextWithAttrs(context.Background(), Attrs{"request": "1"})
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				_, _ = db.ExecContext(ctx, "UPDATE users SET name = 'joe'")
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "UPDATE users SET name = 'joe' /*key='value'*/", 0)
+			},
+		},
+		{
+			name: "session statements on connect and reset",
+			options: []Option{
+				WithAttrPairs("key", "value"),
+				WithSessionStatement(SetStatement("application_name"), AttrProviderFunc(func(ctx context.Context) Attrs {
+					return AttrPairs("application", "hello")
+				})),
+				WithSessionStatement(SetStatement("@@session.query_tag"), AttrProviderFunc(func(ctx context.Context) Attrs {
+					return AttrsFromContext(ctx)
+				})),
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				for _, request := range []string{"22", "23"} {
+					rows, err := db.QueryContext(ContextWithAttrs(ctx, Attrs{"request": request}), "SELECT 1")
+					assertNoError(t, err)
+					_ = rows.Close()
+				}
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "SET application_name = 'application=''hello'''", 0)
+				conn.assertExecContext(t, "SET application_name = 'application=''hello'''", 2)
+				conn.assertExecContext(t, "SET @@session.query_tag = 'request=''23'''", 3)
+				conn.assertQueryContext(t, "SELECT 1 /*key='value',request='22'*/", 0)
+				conn.assertQueryContext(t, "SELECT 1 /*key='value',request='23'*/", 1)
+			},
+		},
+		{
+			name: "session statements unchanged on reset",
+			options: []Option{
+				WithSessionStatement(SetStatement("application_name"), AttrProviderFunc(func(ctx context.Context) Attrs {
+					return AttrPairs("application", "hello")
+				})),
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				db.SetMaxOpenConns(1)
+				for i := 0; i < 3; i++ {
+					rows, err := db.QueryContext(ctx, "SELECT 1")
+					assertNoError(t, err)
+					_ = rows.Close()
+				}
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "SET application_name = 'application=''hello'''", 0)
+				if len(conn.execContext) != 1 {
+					t.Errorf("got '%v', want '%v'", len(conn.execContext), 1)
+				}
+			},
+		},
+		{
+			name: "PrepareContext volatile keys with statement",
+			options: []Option{
+				WithAttrPairs("key", "value"),
+				WithVolatileKeys("request"),
+				WithVolatileStatement(SetStatement("application_name")),
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				conn, err := db.Conn(ctx)
+				assertNoError(t, err)
+				defer conn.Close()
+
+				first := ContextWithAttrs(ctx, Attrs{"request": "1"})
+				second := ContextWithAttrs(ctx, Attrs{"request": "2"})
+				stmt, err := conn.PrepareContext(first, "SELECT 1")
+				assertNoError(t, err)
+				defer stmt.Close()
+
+				for _, queryCtx := range []context.Context{first, second} {
+					rows, err := stmt.QueryContext(queryCtx)
+					assertNoError(t, err)
+					_ = rows.Close()
+				}
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertPrepareContext(t, "SELECT 1 /*key='value'*/", 0)
+				conn.assertExecContext(t, "SET application_name = 'request=''1'''", 0)
+				conn.assertExecContext(t, "SET application_name = 'request=''2'''", 1)
+				if len(conn.execContext) != 2 {
+					t.Errorf("got '%v', want '%v'", len(conn.execContext), 2)
+				}
+				conn.assertQueryContext(t, "SELECT 1 /*key='value'*/", 0)
+				conn.assertQueryContext(t, "SELECT 1 /*key='value'*/", 1)
+			},
+		},
+		{
+			name: "QueryContext volatile keys with max comment length",
+			options: []Option{
+				WithAttrPairs("key", "value"),
+				WithMaxCommentLength(20),
+				WithVolatileKeys("span", "trace"),
+				WithVolatileStatement(SetStatement("application_name")),
+			},
+			makeCtx: func() context.Context {
+				return ContextWithAttrs(context.Background(), Attrs{"span": "s1", "trace": "t1"})
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				rows, err := db.QueryContext(ctx, "SELECT 1")
+				assertNoError(t, err)
+				_ = rows.Close()
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "SET application_name = 'span=''s1'''", 0)
+				conn.assertQueryContext(t, "SELECT 1 /*key='value'*/", 0)
+			},
+		},
+		{
+			name: "volatile statement after rollback",
+			options: []Option{
+				WithVolatileKeys("request"),
+				WithVolatileStatement(SetStatement("application_name")),
+			},
+			makeCtx: func() context.Context {
+				return ContextWithAttrs(context.Background(), Attrs{"request": "1"})
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				conn, err := db.Conn(ctx)
+				assertNoError(t, err)
+				defer conn.Close()
+
+				tx, err := conn.BeginTx(ctx, nil)
+				assertNoError(t, err)
+				_, err = tx.ExecContext(ctx, "UPDATE users SET name = 'joe'")
+				assertNoError(t, err)
+				assertNoError(t, tx.Rollback())
+
+				_, err = conn.ExecContext(ctx, "UPDATE users SET name = 'joe'")
+				assertNoError(t, err)
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "SET application_name = 'request=''1'''", 0)
+				conn.assertExecContext(t, "UPDATE users SET name = 'joe'", 1)
+				conn.assertExecContext(t, "SET application_name = 'request=''1'''", 2)
+				conn.assertExecContext(t, "UPDATE users SET name = 'joe'", 3)
+			},
+		},
+		{
+			name: "volatile statement in aborted transaction",
+			options: []Option{
+				WithVolatileKeys("request"),
+				WithVolatileStatement(SetStatement("application_name")),
+			},
+			failExec: "SET application_name = 'request=''2'''",
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				first := ContextWithAttrs(ctx, Attrs{"request": "1"})
+				second := ContextWithAttrs(ctx, Attrs{"request": "2"})
+
+				tx, err := db.BeginTx(first, nil)
+				assertNoError(t, err)
+				_, err = tx.ExecContext(first, "UPDATE users SET name = 'joe'")
+				assertNoError(t, err)
+				_, err = tx.ExecContext(second, "ROLLBACK TO SAVEPOINT s1")
+				assertNoError(t, err)
+				_, err = tx.ExecContext(second, "UPDATE users SET name = 'ann'")
+				assertNoError(t, err)
+				assertNoError(t, tx.Commit())
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "SET application_name = 'request=''1'''", 0)
+				conn.assertExecContext(t, "UPDATE users SET name = 'joe'", 1)
+				conn.assertExecContext(t, "ROLLBACK TO SAVEPOINT s1", 2)
+				conn.assertExecContext(t, "SET application_name = 'request=''2'''", 3)
+				conn.assertExecContext(t, "UPDATE users SET name = 'ann'", 4)
+			},
+		},
+		{
+			name: "volatile statement after session reset",
+			options: []Option{
+				WithSessionStatement(SetStatement("application_name"), AttrProviderFunc(func(ctx context.Context) Attrs {
+					return AttrPairs("tenant", AttrsFromContext(ctx)["tenant"])
+				})),
+				WithVolatileKeys("trace"),
+				WithVolatileStatement(SetStatement("application_name")),
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				db.SetMaxOpenConns(1)
+				for _, tenant := range []string{"a", "b"} {
+					rows, err := db.QueryContext(ContextWithAttrs(ctx, Attrs{"tenant": tenant, "trace": "t1"}), "SELECT 1")
+					assertNoError(t, err)
+					_ = rows.Close()
+				}
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "SET application_name = 'trace=''t1'''", 1)
+				conn.assertExecContext(t, "SET application_name = 'tenant=''b'''", 2)
+				conn.assertExecContext(t, "SET application_name = 'trace=''t1'''", 3)
+			},
+		},
+		{
+			name: "volatile statement kept when session is unchanged",
+			options: []Option{
+				WithSessionStatement(SetStatement("application_name"), AttrProviderFunc(func(ctx context.Context) Attrs {
+					return AttrPairs("app", "x")
+				})),
+				WithVolatileKeys("trace"),
+				WithVolatileStatement(SetStatement("application_name")),
+			},
+			makeCtx: func() context.Context {
+				return ContextWithAttrs(context.Background(), Attrs{"trace": "t1"})
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				db.SetMaxOpenConns(1)
+				for i := 0; i < 2; i++ {
+					rows, err := db.QueryContext(ctx, "SELECT 1")
+					assertNoError(t, err)
+					_ = rows.Close()
+				}
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "SET application_name = 'app=''x'''", 0)
+				conn.assertExecContext(t, "SET application_name = 'trace=''t1'''", 1)
+				if len(conn.execContext) != 2 {
+					t.Errorf("got '%v', want '%v'", len(conn.execContext), 2)
+				}
+				conn.assertQueryContext(t, "SELECT 1", 0)
+				conn.assertQueryContext(t, "SELECT 1", 1)
+			},
+		},
+		{
+			name:    "ExecContext without comment",
+			options: []Option{WithAttrPairs("key", "value")},
+			makeCtx: func() context.Context {
+				return WithoutComment(context.Background())
+			},
+			perform: func(t *testing.T, ctx context.Context, db *sql.DB) {
+				_, _ = db.ExecContext(ctx, "CREATE TABLE users (id int)")
+			},
+			assert: func(t *testing.T, conn *mockConn) {
+				conn.assertExecContext(t, "CREATE TABLE users (id int)", 0)
+			},
+		},
 	}
 
 	drivers := []struct {
@@ -156,7 +654,7 @@ func TestWrapDriver(t *testing.T) {
 					ctx = context.Background()
 				}
 
-				conn := &mockConn{}
+				conn := &mockConn{failExec: cs.failExec}
 				orig := drv.newDriver(conn)
 				drv := WrapDriver(orig, cs.options...)
 
@@ -174,6 +672,49 @@ func TestWrapDriver(t *testing.T) {
 	}
 }
 
+func TestWrapConnector(t *testing.T) {
+	conn := &mockConn{}
+	orig := &mockConnector{
+		drv:  &mockDriverContext{conn: conn},
+		conn: conn,
+	}
+	ctr := WrapConnector(orig, WithAttrPairs("key", "value"))
+
+	db := sql.OpenDB(ctr)
+	defer db.Close()
+
+	ctx := context.Background()
+	_, _ = db.QueryContext(ctx, "SELECT 1")
+	_, _ = db.ExecContext(ctx, "UPDATE users SET name = 'joe'")
+
+	conn.assertQueryContext(t, "SELECT 1 /*key='value'*/", 0)
+	conn.assertExecContext(t, "UPDATE users SET name = 'joe' /*key='value'*/", 0)
+
+	drv, ok := ctr.Driver().(*commentDriver)
+	if !ok {
+		t.Fatalf("got '%T', want '%T'", ctr.Driver(), drv)
+	}
+	if drv.drv != orig.drv {
+		t.Errorf("got '%v', want '%v'", drv.drv, orig.drv)
+	}
+}
+
+func TestWrapConnectorClose(t *testing.T) {
+	conn := &mockConn{}
+	orig := &mockCloserConnector{
+		mockConnector: &mockConnector{
+			drv:  &mockDriverContext{conn: conn},
+			conn: conn,
+		},
+	}
+
+	db := sql.OpenDB(WrapConnector(orig))
+	assertNoError(t, db.Close())
+	if !orig.closed {
+		t.Errorf("got '%v', want '%v'", orig.closed, true)
+	}
+}
+
 type contextKey int
 
 const contextUserKey contextKey = 0
@@ -203,8 +744,16 @@ func (m *mockDriverContext) OpenConnector(name string) (driver.Connector, error)
 
 type mockConn struct {
 	driver.Conn
-	execContext  []string
-	queryContext []string
+	execContext    []string
+	queryContext   []string
+	prepareContext []string
+	// failExec is statement failing in ExecContext.
+	failExec string
+}
+
+func (m *mockConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	m.prepareContext = append(m.prepareContext, query)
+	return &mockStmt{conn: m, query: query}, nil
 }
 
 func (m *mockConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
@@ -214,6 +763,9 @@ func (m *mockConn) QueryContext(ctx context.Context, query string, args []driver
 
 func (m *mockConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
 	m.execContext = append(m.execContext, query)
+	if m.failExec != "" && query == m.failExec {
+		return nil, errors.New("current transaction is aborted")
+	}
 	return nil, nil
 }
 
@@ -261,6 +813,22 @@ func (m *mockConn) assertExecContext(t *testing.T, query string, idx int) {
 	}
 }
 
+func (m *mockConn) assertPrepareContext(t *testing.T, query string, idx int) {
+	t.Helper()
+
+	if idx+1 > len(m.prepareContext) {
+		t.Errorf("invalid idx '%v' from '%v'", idx, len(m.prepareContext))
+	}
+
+	for i, q := range m.prepareContext {
+		if i == idx {
+			if q != query {
+				t.Errorf("got '%v', want '%v'", m.prepareContext, query)
+			}
+		}
+	}
+}
+
 func assertNoError(t *testing.T, err error) {
 	t.Helper()
 
@@ -282,6 +850,16 @@ func (m *mockConnector) Driver() driver.Driver {
 	return m.drv
 }
 
+type mockCloserConnector struct {
+	*mockConnector
+	closed bool
+}
+
+func (m *mockCloserConnector) Close() error {
+	m.closed = true
+	return nil
+}
+
 type mockDriver struct {
 	conn *mockConn
 }
@@ -300,6 +878,37 @@ func (m *mockTx) Rollback() error {
 	return nil
 }
 
+type mockStmt struct {
+	conn  *mockConn
+	query string
+}
+
+func (m *mockStmt) Close() error {
+	return nil
+}
+
+func (m *mockStmt) NumInput() int {
+	return -1
+}
+
+func (m *mockStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return m.ExecContext(context.Background(), nil)
+}
+
+func (m *mockStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return m.QueryContext(context.Background(), nil)
+}
+
+func (m *mockStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	m.conn.execContext = append(m.conn.execContext, m.query)
+	return nil, nil
+}
+
+func (m *mockStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	m.conn.queryContext = append(m.conn.queryContext, m.query)
+	return &mockRows{}, nil
+}
+
 type mockRows struct {
 }
 
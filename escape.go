@@ -5,6 +5,8 @@ package sqlcommenter
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 )
 
 const upperhex = "0123456789ABCDEF"
@@ -75,3 +77,125 @@ func shouldEscape(c byte, query bool) bool {
 	}
 	return true
 }
+
+func queryUnescape(s string) (string, error) {
+	return unescape(s, true)
+}
+
+func pathUnescape(s string) (string, error) {
+	return unescape(s, false)
+}
+
+func unescape(s string, query bool) (string, error) {
+	n := 0
+	hasPlus := false
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '%':
+			n++
+			if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+				s = s[i:]
+				if len(s) > 3 {
+					s = s[:3]
+				}
+				return "", fmt.Errorf("invalid escape %q", s)
+			}
+			i += 3
+		case '+':
+			hasPlus = query
+			i++
+		default:
+			i++
+		}
+	}
+
+	if n == 0 && !hasPlus {
+		return s, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) - 2*n)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '%':
+			b.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+			i += 2
+		case '+':
+			if query {
+				b.WriteByte(' ')
+			} else {
+				b.WriteByte('+')
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func isHex(c byte) bool {
+	switch {
+	case '0' <= c && c <= '9':
+		return true
+	case 'a' <= c && c <= 'f':
+		return true
+	case 'A' <= c && c <= 'F':
+		return true
+	}
+	return false
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10
+	}
+	return 0
+}
+
+// escapedLen returns length of s after escaping.
+func escapedLen(s string, query bool) int {
+	n := len(s)
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; shouldEscape(c, query) && (c != ' ' || !query) {
+			n += 2
+		}
+	}
+	return n
+}
+
+// truncateEscaped truncates s so that its escaped length does not exceed max.
+// Escape sequences and UTF-8 sequences are never split.
+func truncateEscaped(s string, max int, query bool) string {
+	n := 0
+	for i := 0; i < len(s); {
+		size := utf8SeqLen(s[i])
+		if i+size > len(s) {
+			size = len(s) - i
+		}
+		width := escapedLen(s[i:i+size], query)
+		if n+width > max {
+			return s[:i]
+		}
+		n += width
+		i += size
+	}
+	return s
+}
+
+func utf8SeqLen(c byte) int {
+	switch {
+	case c < 0xC0:
+		return 1
+	case c < 0xE0:
+		return 2
+	case c < 0xF0:
+		return 3
+	default:
+		return 4
+	}
+}
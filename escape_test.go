@@ -91,3 +91,62 @@ func TestPathEscape(t *testing.T) {
 		})
 	}
 }
+
+func TestTruncateEscaped(t *testing.T) {
+	cases := []struct {
+		in    string
+		max   int
+		query bool
+		want  string
+	}{
+		{
+			in:   "abc",
+			max:  5,
+			want: "abc",
+		},
+		{
+			in:   "abcdef",
+			max:  3,
+			want: "abc",
+		},
+		{
+			in:   "a/b",
+			max:  3,
+			want: "a",
+		},
+		{
+			in:   "a/b",
+			max:  4,
+			want: "a/",
+		},
+		{
+			in:   "a☺b",
+			max:  9,
+			want: "a",
+		},
+		{
+			in:   "a☺b",
+			max:  10,
+			want: "a☺",
+		},
+		{
+			in:    "a b",
+			max:   2,
+			query: true,
+			want:  "a ",
+		},
+		{
+			in:   "a b",
+			max:  2,
+			want: "a",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.in, func(t *testing.T) {
+			if got := truncateEscaped(cs.in, cs.max, cs.query); cs.want != got {
+				t.Errorf("got %q, want %q", got, cs.want)
+			}
+		})
+	}
+}
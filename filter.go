@@ -0,0 +1,75 @@
+package sqlcommenter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+)
+
+// RedactedValue replaces values redacted with RedactReplace.
+const RedactedValue = "REDACTED"
+
+// redactHashPrefix prefixes values redacted with RedactHash.
+const redactHashPrefix = "sha256:"
+
+// redactHMACPrefix prefixes values redacted with RedactHash when redaction key is configured.
+const redactHMACPrefix = "hmac-sha256:"
+
+type redaction struct {
+	pattern *regexp.Regexp
+	mode    RedactMode
+}
+
+func (r redaction) apply(value string, key []byte) (string, bool) {
+	if !r.pattern.MatchString(value) {
+		return value, false
+	}
+	if r.mode == RedactHash {
+		return redactHash(value, key), true
+	}
+	return RedactedValue, true
+}
+
+// redactHash returns truncated HMAC-SHA256 of value when key is set, SHA-256 otherwise.
+func redactHash(value string, key []byte) string {
+	if key == nil {
+		sum := sha256.Sum256([]byte(value))
+		return redactHashPrefix + hex.EncodeToString(sum[:8])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return redactHMACPrefix + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// filter applies allowed keys, denied keys and redactions to attrs in b.
+func (c *commenter) filter(b *AttrBuilder) {
+	if c.allowedKeys != nil || c.deniedKeys != nil {
+		b.remove(func(attr Attr) bool {
+			return !c.keyAllowed(attr.Key)
+		})
+	}
+	if len(c.redactions) == 0 {
+		return
+	}
+
+	for i := range b.attrs {
+		for _, r := range c.redactions {
+			if v, ok := r.apply(b.attrs[i].Value, c.redactionKey); ok {
+				b.attrs[i] = builderAttr{Attr: Attr{Key: b.attrs[i].Key, Value: v}}
+				break
+			}
+		}
+	}
+}
+
+func (c *commenter) keyAllowed(key string) bool {
+	if _, ok := c.deniedKeys[key]; ok {
+		return false
+	}
+	if c.allowedKeys == nil {
+		return true
+	}
+	_, ok := c.allowedKeys[key]
+	return ok
+}
@@ -0,0 +1,79 @@
+package sqlcommenter
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+$`)
+
+func TestCommentFilter(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "allowed keys",
+			opts: []Option{WithAllowedKeys("app", "route")},
+			want: "SELECT 1 /*app='hello'*/",
+		},
+		{
+			name: "denied keys",
+			opts: []Option{WithDeniedKeys("email", "token")},
+			want: "SELECT 1 /*app='hello'*/",
+		},
+		{
+			name: "allowed and denied keys",
+			opts: []Option{WithAllowedKeys("app", "email"), WithDeniedKeys("email")},
+			want: "SELECT 1 /*app='hello'*/",
+		},
+		{
+			name: "all keys denied",
+			opts: []Option{WithAllowedKeys("route")},
+			want: "SELECT 1",
+		},
+		{
+			name: "redact replace",
+			opts: []Option{WithRedaction(emailPattern, RedactReplace)},
+			want: "SELECT 1 /*app='hello',email='REDACTED',token='secret'*/",
+		},
+		{
+			name: "redact hash",
+			opts: []Option{WithRedaction(emailPattern, RedactHash)},
+			want: "SELECT 1 /*app='hello',email='sha256:2481f36dfc515ca7',token='secret'*/",
+		},
+		{
+			name: "redact hash with key",
+			opts: []Option{WithRedaction(emailPattern, RedactHash), WithRedactionKey([]byte("key"))},
+			want: "SELECT 1 /*app='hello',email='hmac-sha256:6f0ec46226affa16',token='secret'*/",
+		},
+		{
+			name: "first redaction wins",
+			opts: []Option{
+				WithRedaction(regexp.MustCompile(`^secret$`), RedactReplace),
+				WithRedaction(regexp.MustCompile(`^sec`), RedactHash),
+			},
+			want: "SELECT 1 /*app='hello',email='joe@example.com',token='REDACTED'*/",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			attrs := Attrs{"app": "hello", "email": "joe@example.com", "token": "secret"}
+			orig := Attrs{}
+			orig.Update(attrs)
+
+			opts := append([]Option{WithAttrs(attrs)}, cs.opts...)
+			got := Comment(context.Background(), "SELECT 1", opts...)
+			if want := cs.want; want != got {
+				t.Errorf("got '%v', want '%v'", got, want)
+			}
+			if !reflect.DeepEqual(attrs, orig) {
+				t.Errorf("got '%v', want '%v'", attrs, orig)
+			}
+		})
+	}
+}
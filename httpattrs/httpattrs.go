@@ -0,0 +1,154 @@
+// Package httpattrs provides sqlcommenter.AttrProvider emitting standard framework attrs for net/http.
+package httpattrs
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/jbub/sqlcommenter"
+)
+
+const (
+	// KeyRoute is the attribute key of route pattern.
+	KeyRoute = "route"
+	// KeyController is the attribute key of handler name.
+	KeyController = "controller"
+	// KeyAction is the attribute key of request method.
+	KeyAction = "action"
+	// KeyFramework is the attribute key of framework name.
+	KeyFramework = "framework"
+)
+
+// DefaultFramework is the framework name emitted by Provider by default.
+const DefaultFramework = "net/http"
+
+var (
+	_ sqlcommenter.AttrProvider = (*Provider)(nil)
+	_ sqlcommenter.AttrAppender = (*Provider)(nil)
+)
+
+type contextKey int
+
+const contextKeyRoute contextKey = 0
+
+// Route represents route information of request.
+type Route struct {
+	// Pattern is the path part of matched http.ServeMux pattern.
+	Pattern string
+	// Method is the request method.
+	Method string
+	// Handler is the name of handler.
+	Handler string
+}
+
+// ContextWithRoute returns copy of ctx carrying Route.
+func ContextWithRoute(ctx context.Context, route Route) context.Context {
+	return context.WithValue(ctx, contextKeyRoute, route)
+}
+
+// RouteFromContext returns Route stored in ctx.
+func RouteFromContext(ctx context.Context) (Route, bool) {
+	route, ok := ctx.Value(contextKeyRoute).(Route)
+	return route, ok
+}
+
+// Handler wraps h storing Route of request in its context.
+// It has to be registered in http.ServeMux for Request.Pattern to be available.
+func Handler(name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := Route{
+			Pattern: patternPath(r.Pattern),
+			Method:  r.Method,
+			Handler: name,
+		}
+		h.ServeHTTP(w, r.WithContext(ContextWithRoute(r.Context(), route)))
+	})
+}
+
+// HandlerFunc wraps fn storing Route of request in its context.
+func HandlerFunc(name string, fn http.HandlerFunc) http.Handler {
+	return Handler(name, fn)
+}
+
+// patternPath strips method and host from http.ServeMux pattern.
+func patternPath(pattern string) string {
+	if idx := strings.IndexAny(pattern, " \t"); idx != -1 {
+		pattern = strings.TrimLeft(pattern[idx:], " \t")
+	}
+	if idx := strings.IndexByte(pattern, '/'); idx != -1 {
+		pattern = pattern[idx:]
+	}
+	return pattern
+}
+
+// Option configures Provider.
+type Option func(p *Provider)
+
+// WithFramework configures Provider with framework name.
+// Empty name disables emitting of framework attr.
+func WithFramework(name string) Option {
+	return func(p *Provider) {
+		p.framework = name
+	}
+}
+
+// NewProvider returns new Provider configured with provided options.
+func NewProvider(opts ...Option) *Provider {
+	p := &Provider{
+		framework: DefaultFramework,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Provider provides route, controller, action and framework Attrs from Route found in context.Context.
+type Provider struct {
+	framework string
+}
+
+// GetAttrs returns Attrs.
+func (p *Provider) GetAttrs(ctx context.Context) sqlcommenter.Attrs {
+	route, ok := RouteFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	attrs := make(sqlcommenter.Attrs, 4)
+	if route.Pattern != "" {
+		attrs[KeyRoute] = route.Pattern
+	}
+	if route.Handler != "" {
+		attrs[KeyController] = route.Handler
+	}
+	if route.Method != "" {
+		attrs[KeyAction] = route.Method
+	}
+	if p.framework != "" {
+		attrs[KeyFramework] = p.framework
+	}
+	return attrs
+}
+
+// AppendAttrs appends Attrs to b without allocating.
+func (p *Provider) AppendAttrs(ctx context.Context, b *sqlcommenter.AttrBuilder) {
+	route, ok := RouteFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	if route.Pattern != "" {
+		b.Add(KeyRoute, route.Pattern)
+	}
+	if route.Handler != "" {
+		b.Add(KeyController, route.Handler)
+	}
+	if route.Method != "" {
+		b.Add(KeyAction, route.Method)
+	}
+	if p.framework != "" {
+		b.Add(KeyFramework, p.framework)
+	}
+}
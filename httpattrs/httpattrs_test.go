@@ -0,0 +1,112 @@
+package httpattrs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/jbub/sqlcommenter"
+)
+
+func TestHandler(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		handler string
+		method  string
+		target  string
+		opts    []Option
+		want    sqlcommenter.Attrs
+	}{
+		{
+			name:    "pattern with method",
+			pattern: "GET /users/{id}",
+			handler: "getUser",
+			method:  http.MethodGet,
+			target:  "/users/22",
+			want: sqlcommenter.Attrs{
+				KeyRoute:      "/users/{id}",
+				KeyController: "getUser",
+				KeyAction:     "GET",
+				KeyFramework:  "net/http",
+			},
+		},
+		{
+			name:    "pattern without method",
+			pattern: "/users/",
+			handler: "users",
+			method:  http.MethodPost,
+			target:  "/users/22/posts",
+			want: sqlcommenter.Attrs{
+				KeyRoute:      "/users/",
+				KeyController: "users",
+				KeyAction:     "POST",
+				KeyFramework:  "net/http",
+			},
+		},
+		{
+			name:    "pattern with host",
+			pattern: "DELETE example.com/users/{id}",
+			handler: "deleteUser",
+			method:  http.MethodDelete,
+			target:  "http://example.com/users/22",
+			opts:    []Option{WithFramework("custom")},
+			want: sqlcommenter.Attrs{
+				KeyRoute:      "/users/{id}",
+				KeyController: "deleteUser",
+				KeyAction:     "DELETE",
+				KeyFramework:  "custom",
+			},
+		},
+		{
+			name:    "without handler name and framework",
+			pattern: "GET /",
+			method:  http.MethodGet,
+			target:  "/",
+			opts:    []Option{WithFramework("")},
+			want: sqlcommenter.Attrs{
+				KeyRoute:  "/",
+				KeyAction: "GET",
+			},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			var got sqlcommenter.Attrs
+			prov := NewProvider(cs.opts...)
+
+			mux := http.NewServeMux()
+			mux.Handle(cs.pattern, HandlerFunc(cs.handler, func(w http.ResponseWriter, r *http.Request) {
+				got = prov.GetAttrs(r.Context())
+			}))
+
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(cs.method, cs.target, nil))
+			if !reflect.DeepEqual(cs.want, got) {
+				t.Errorf("got '%v', want '%v'", got, cs.want)
+			}
+		})
+	}
+}
+
+func TestProviderNoRoute(t *testing.T) {
+	if got := NewProvider().GetAttrs(context.Background()); got != nil {
+		t.Errorf("got '%v', want '%v'", got, nil)
+	}
+}
+
+func TestProviderComment(t *testing.T) {
+	ctx := ContextWithRoute(context.Background(), Route{
+		Pattern: "/users/{id}",
+		Method:  http.MethodGet,
+		Handler: "getUser",
+	})
+
+	got := sqlcommenter.Comment(ctx, "SELECT 1", sqlcommenter.WithAttrProvider(NewProvider()))
+	want := "SELECT 1 /*action='GET',controller='getUser',framework='net%2Fhttp',route='%2Fusers%2F%7Bid%7D'*/"
+	if got != want {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package sqlcommenter
+
+import (
+	"slices"
+)
+
+// limit applies maximum value and comment lengths to attrs in b.
+func (c *commenter) limit(b *AttrBuilder) {
+	if c.maxValueLen > 0 {
+		for i, attr := range b.attrs {
+			if escapedLen(attr.Value, false) > c.maxValueLen {
+				b.attrs[i] = builderAttr{Attr: Attr{Key: attr.Key, Value: truncateEscaped(attr.Value, c.maxValueLen, false)}}
+				c.truncated(attr.Key, false)
+			}
+		}
+	}
+	if c.maxCommentLen <= 0 {
+		return
+	}
+
+	size := encodedLen(b.attrs)
+	for size > c.maxCommentLen && len(b.attrs) > 0 {
+		i := c.nextDrop(b.attrs)
+		key := b.attrs[i].Key
+		size -= attrLen(b.attrs[i])
+		if len(b.attrs) > 1 {
+			size-- // separator
+		}
+		b.attrs = slices.Delete(b.attrs, i, i+1)
+		c.truncated(key, true)
+	}
+}
+
+// nextDrop returns index of attr which is dropped next to satisfy maxCommentLen.
+func (c *commenter) nextDrop(attrs []builderAttr) int {
+	drop := 0
+	for i := 1; i < len(attrs); i++ {
+		if c.dropsBefore(attrs[i].Key, attrs[drop].Key) {
+			drop = i
+		}
+	}
+	return drop
+}
+
+// dropsBefore reports whether key a is dropped before key b.
+// Keys without priority are dropped first starting from the last encoded key,
+// followed by keys with priority starting from the lowest one.
+func (c *commenter) dropsBefore(a string, b string) bool {
+	pa, oka := c.keyPriority[a]
+	pb, okb := c.keyPriority[b]
+	switch {
+	case oka && okb:
+		return pa < pb
+	case oka != okb:
+		return okb
+	default:
+		return a > b
+	}
+}
+
+func (c *commenter) truncated(key string, dropped bool) {
+	if c.truncateFunc != nil {
+		c.truncateFunc(key, dropped)
+	}
+}
+
+// encodedLen returns length of comment encoded from attrs.
+func encodedLen(attrs []builderAttr) int {
+	n := len(commentStart) + len(commentEnd)
+	for _, attr := range attrs {
+		n += attrLen(attr)
+	}
+	if len(attrs) > 1 {
+		n += len(attrs) - 1
+	}
+	return n
+}
+
+// attrLen returns length of encoded attr.
+func attrLen(attr builderAttr) int {
+	if attr.encoded != "" {
+		return len(attr.encoded)
+	}
+	return pairLen(attr.Key, attr.Value)
+}
+
+// pairLen returns length of single encoded key value pair.
+func pairLen(key string, value string) int {
+	return escapedLen(key, true) + len("=''") + escapedLen(value, false)
+}
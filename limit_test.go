@@ -0,0 +1,115 @@
+package sqlcommenter
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCommentLimit(t *testing.T) {
+	cases := []struct {
+		name          string
+		opts          []Option
+		want          string
+		wantTruncated []string
+	}{
+		{
+			name: "no limits",
+			want: "SELECT 1 /*app='hello',route='%2Fusers%2F%7Bid%7D',user='joe'*/",
+		},
+		{
+			name:          "max value length",
+			opts:          []Option{WithMaxValueLength(8)},
+			want:          "SELECT 1 /*app='hello',route='%2Fusers',user='joe'*/",
+			wantTruncated: []string{"route:false"},
+		},
+		{
+			name:          "max value length does not split escape",
+			opts:          []Option{WithMaxValueLength(5)},
+			want:          "SELECT 1 /*app='hello',route='%2Fus',user='joe'*/",
+			wantTruncated: []string{"route:false"},
+		},
+		{
+			name:          "max value length does not split escape sequence boundary",
+			opts:          []Option{WithMaxValueLength(2)},
+			want:          "SELECT 1 /*app='he',route='',user='jo'*/",
+			wantTruncated: []string{"app:false", "route:false", "user:false"},
+		},
+		{
+			name: "max comment length fits",
+			opts: []Option{WithMaxCommentLength(64)},
+			want: "SELECT 1 /*app='hello',route='%2Fusers%2F%7Bid%7D',user='joe'*/",
+		},
+		{
+			name:          "max comment length drops last keys",
+			opts:          []Option{WithMaxCommentLength(52)},
+			want:          "SELECT 1 /*app='hello',route='%2Fusers%2F%7Bid%7D'*/",
+			wantTruncated: []string{"user:true"},
+		},
+		{
+			name:          "max comment length with priority",
+			opts:          []Option{WithMaxCommentLength(30), WithKeyPriority("user", "route")},
+			want:          "SELECT 1 /*user='joe'*/",
+			wantTruncated: []string{"app:true", "route:true"},
+		},
+		{
+			name:          "max comment length with value length",
+			opts:          []Option{WithMaxCommentLength(40), WithMaxValueLength(8)},
+			want:          "SELECT 1 /*app='hello',route='%2Fusers'*/",
+			wantTruncated: []string{"route:false", "user:true"},
+		},
+		{
+			name:          "max comment length drops all keys",
+			opts:          []Option{WithMaxCommentLength(4)},
+			want:          "SELECT 1",
+			wantTruncated: []string{"app:true", "route:true", "user:true"},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			var truncated []string
+			opts := append([]Option{
+				WithAttrPairs("app", "hello", "route", "/users/{id}", "user", "joe"),
+				WithTruncateFunc(func(key string, dropped bool) {
+					if dropped {
+						truncated = append(truncated, key+":true")
+					} else {
+						truncated = append(truncated, key+":false")
+					}
+				}),
+			}, cs.opts...)
+
+			got := Comment(context.Background(), "SELECT 1", opts...)
+			if want := cs.want; want != got {
+				t.Errorf("got '%v', want '%v'", got, want)
+			}
+			sort.Strings(truncated)
+			if !reflect.DeepEqual(truncated, cs.wantTruncated) {
+				t.Errorf("got '%v', want '%v'", truncated, cs.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestEncodedLen(t *testing.T) {
+	cases := []Attrs{
+		{},
+		{"key": "value"},
+		{"my key": "my value", "route": "/users/{id}", "emoji": "☺"},
+	}
+
+	for _, attrs := range cases {
+		ab := &AttrBuilder{}
+		ab.AddAttrs(attrs)
+		ab.sort()
+
+		var b bytes.Buffer
+		writeComment(ab.attrs, &b)
+		if got, want := encodedLen(ab.attrs), b.Len(); got != want {
+			t.Errorf("got '%v', want '%v'", got, want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package sqlcommenter
+
+import (
+	"context"
+)
+
+const (
+	// KeyDBOperation is the attribute key of statement type detected by OperationProvider.
+	KeyDBOperation = "db_operation"
+	// KeyDBTable is the attribute key of the first target table detected by OperationProvider.
+	KeyDBTable = "db_table"
+)
+
+// Statement types detected by OperationProvider.
+const (
+	OperationSelect  = "SELECT"
+	OperationInsert  = "INSERT"
+	OperationUpdate  = "UPDATE"
+	OperationDelete  = "DELETE"
+	OperationMerge   = "MERGE"
+	OperationReplace = "REPLACE"
+	OperationDDL     = "DDL"
+)
+
+var _ QueryAttrProvider = (*OperationProvider)(nil)
+
+// NewOperationProvider returns new OperationProvider.
+func NewOperationProvider() *OperationProvider {
+	return &OperationProvider{}
+}
+
+// OperationProvider provides statement type and the first target table detected from query,
+// use it with WithQueryAttrProvider. Statements of other types are left without attrs
+// and table is omitted when it can not be determined, for example when selecting from subquery.
+// Query is tokenized according to Dialect of commenter, called directly it uses DialectGeneric.
+type OperationProvider struct{}
+
+// GetQueryAttrs returns Attrs.
+func (p *OperationProvider) GetQueryAttrs(ctx context.Context, query string) Attrs {
+	return p.getDialectQueryAttrs(ctx, query, DialectGeneric)
+}
+
+func (p *OperationProvider) getDialectQueryAttrs(ctx context.Context, query string, d Dialect) Attrs {
+	op, table := detectOperation(query, d)
+	if op == "" {
+		return nil
+	}
+
+	attrs := Attrs{KeyDBOperation: op}
+	if table != "" {
+		attrs[KeyDBTable] = table
+	}
+	return attrs
+}
+
+// dmlKeywords are statements which may follow common table expressions.
+var dmlKeywords = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "MERGE"}
+
+// ddlKeywords are statements reported as OperationDDL.
+var ddlKeywords = []string{"CREATE", "ALTER", "DROP", "RENAME"}
+
+// ddlModifiers are keywords which may precede object type in DDL statements.
+var ddlModifiers = []string{"OR", "REPLACE", "GLOBAL", "LOCAL", "TEMP", "TEMPORARY", "UNLOGGED", "UNIQUE", "CLUSTERED", "NONCLUSTERED"}
+
+// insertModifiers are keywords which may precede INTO in INSERT and REPLACE statements.
+var insertModifiers = []string{"OR", "REPLACE", "ROLLBACK", "ABORT", "FAIL", "IGNORE", "LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY"}
+
+// tableModifiers are keywords which may precede table name.
+var tableModifiers = []string{"ONLY", "IF", "NOT", "EXISTS", "LOW_PRIORITY", "IGNORE"}
+
+// detectOperation returns statement type and the first target table of query in dialect d.
+func detectOperation(query string, d Dialect) (string, string) {
+	t := newTokenizer(query, d)
+	tok, ok := t.next()
+	for ok && tok.isPunct('(') {
+		tok, ok = t.next()
+	}
+	if !ok {
+		return "", ""
+	}
+	if tok.is("WITH") {
+		if tok, ok = t.skipCTEs(); !ok {
+			return "", ""
+		}
+	}
+
+	switch {
+	case tok.is("SELECT"):
+		return OperationSelect, t.tableAfter("FROM")
+	case tok.is("INSERT"):
+		return OperationInsert, t.insertTable()
+	case tok.is("REPLACE"):
+		return OperationReplace, t.insertTable()
+	case tok.is("UPDATE"):
+		return OperationUpdate, t.tableName()
+	case tok.is("DELETE"):
+		return OperationDelete, t.tableAfter("FROM")
+	case tok.is("MERGE"):
+		return OperationMerge, t.tableAfter("INTO")
+	case tok.is("TRUNCATE"):
+		if next, ok := t.peek(); ok && next.is("TABLE") {
+			t.next()
+		}
+		return OperationDDL, t.tableName()
+	case tok.isAny(ddlKeywords):
+		return OperationDDL, t.ddlTable()
+	}
+	return "", ""
+}
+
+// skipCTEs skips common table expressions and returns the first keyword of main statement.
+func (t *tokenizer) skipCTEs() (token, bool) {
+	depth := 0
+	for {
+		tok, ok := t.next()
+		if !ok {
+			return token{}, false
+		}
+		switch {
+		case tok.isPunct('('):
+			depth++
+		case tok.isPunct(')'):
+			depth--
+		case depth == 0 && tok.isAny(dmlKeywords):
+			return tok, true
+		}
+	}
+}
+
+// tableAfter returns name of table following keyword kw in current statement,
+// skipping nested parentheses.
+func (t *tokenizer) tableAfter(kw string) string {
+	depth := 0
+	for {
+		tok, ok := t.next()
+		if !ok || tok.isPunct(';') {
+			return ""
+		}
+		switch {
+		case tok.isPunct('('):
+			depth++
+		case tok.isPunct(')'):
+			if depth == 0 {
+				return ""
+			}
+			depth--
+		case depth == 0 && tok.is(kw):
+			return t.tableName()
+		}
+	}
+}
+
+// insertTable returns target table of INSERT or REPLACE statement, INTO is optional in MySQL.
+// Oracle multitable inserts report table of the first INTO clause.
+func (t *tokenizer) insertTable() string {
+	for {
+		tok, ok := t.peek()
+		switch {
+		case !ok:
+			return ""
+		case tok.isAny(insertModifiers):
+			t.next()
+		case tok.is("ALL"), tok.is("FIRST"):
+			return t.tableAfter("INTO")
+		case tok.is("INTO"):
+			t.next()
+			return t.tableName()
+		default:
+			return t.tableName()
+		}
+	}
+}
+
+// tableName returns possibly qualified table name, skipping preceding modifiers.
+func (t *tokenizer) tableName() string {
+	tok, ok := t.next()
+	for ok && tok.isAny(tableModifiers) {
+		tok, ok = t.next()
+	}
+	if !ok || !tok.isName() {
+		return ""
+	}
+
+	name := tok.text
+	for {
+		dot, ok := t.peek()
+		if !ok || !dot.isPunct('.') {
+			return name
+		}
+		t.next()
+		part, ok := t.next()
+		if !ok || !part.isName() {
+			return name
+		}
+		name += "." + part.text
+	}
+}
+
+// ddlTable returns name of table created, altered or dropped by DDL statement.
+// Index statements report table the index is defined on.
+func (t *tokenizer) ddlTable() string {
+	for {
+		tok, ok := t.next()
+		switch {
+		case !ok:
+			return ""
+		case tok.isAny(ddlModifiers):
+		case tok.is("TABLE"):
+			return t.tableName()
+		case tok.is("INDEX"):
+			return t.tableAfter("ON")
+		default:
+			return ""
+		}
+	}
+}
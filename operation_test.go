@@ -0,0 +1,228 @@
+package sqlcommenter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestOperationProvider(t *testing.T) {
+	cases := []struct {
+		query string
+		want  Attrs
+	}{
+		{
+			query: "",
+			want:  nil,
+		},
+		{
+			query: "BEGIN",
+			want:  nil,
+		},
+		{
+			query: "SELECT 1",
+			want:  Attrs{KeyDBOperation: OperationSelect},
+		},
+		{
+			query: "select id from users where id = $1",
+			want:  Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+		{
+			query: "SELECT (SELECT max(id) FROM orders), name FROM public.users u JOIN orders o ON o.user_id = u.id",
+			want:  Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "public.users"},
+		},
+		{
+			query: "SELECT 'FROM fake' FROM \"my schema\".\"User\"\"s\"",
+			want:  Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "my schema.User\"s"},
+		},
+		{
+			query: "SELECT * FROM `users`",
+			want:  Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+		{
+			query: "SELECT * FROM [dbo].[users]",
+			want:  Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "dbo.users"},
+		},
+		{
+			query: "SELECT * FROM (SELECT * FROM users) t",
+			want:  Attrs{KeyDBOperation: OperationSelect},
+		},
+		{
+			query: "(SELECT id FROM users) UNION (SELECT id FROM admins)",
+			want:  Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+		{
+			query: "/* hint */ -- line\n SELECT $$ FROM fake $$ FROM users",
+			want:  Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+		{
+			query: "WITH recent AS (SELECT * FROM orders), cols (a) AS (SELECT 1) SELECT * FROM recent",
+			want:  Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "recent"},
+		},
+		{
+			query: "WITH deleted AS (DELETE FROM users RETURNING id) INSERT INTO archive SELECT * FROM deleted",
+			want:  Attrs{KeyDBOperation: OperationInsert, KeyDBTable: "archive"},
+		},
+		{
+			query: "INSERT INTO users(name) VALUES ('joe')",
+			want:  Attrs{KeyDBOperation: OperationInsert, KeyDBTable: "users"},
+		},
+		{
+			query: "INSERT IGNORE INTO users (name) VALUES (?)",
+			want:  Attrs{KeyDBOperation: OperationInsert, KeyDBTable: "users"},
+		},
+		{
+			query: "INSERT OR REPLACE INTO users (name) VALUES (?)",
+			want:  Attrs{KeyDBOperation: OperationInsert, KeyDBTable: "users"},
+		},
+		{
+			query: "INSERT users VALUES (?)",
+			want:  Attrs{KeyDBOperation: OperationInsert, KeyDBTable: "users"},
+		},
+		{
+			query: "INSERT ALL INTO users (id) VALUES (1) INTO admins (id) VALUES (1) SELECT 1 FROM dual",
+			want:  Attrs{KeyDBOperation: OperationInsert, KeyDBTable: "users"},
+		},
+		{
+			query: "REPLACE INTO users (name) VALUES (?)",
+			want:  Attrs{KeyDBOperation: OperationReplace, KeyDBTable: "users"},
+		},
+		{
+			query: "REPLACE LOW_PRIORITY users SET name = ?",
+			want:  Attrs{KeyDBOperation: OperationReplace, KeyDBTable: "users"},
+		},
+		{
+			query: "UPDATE ONLY users SET name = 'joe'",
+			want:  Attrs{KeyDBOperation: OperationUpdate, KeyDBTable: "users"},
+		},
+		{
+			query: "DELETE FROM users WHERE id = 1;",
+			want:  Attrs{KeyDBOperation: OperationDelete, KeyDBTable: "users"},
+		},
+		{
+			query: "MERGE INTO users u USING staging s ON u.id = s.id",
+			want:  Attrs{KeyDBOperation: OperationMerge, KeyDBTable: "users"},
+		},
+		{
+			query: "CREATE TABLE IF NOT EXISTS users (id int)",
+			want:  Attrs{KeyDBOperation: OperationDDL, KeyDBTable: "users"},
+		},
+		{
+			query: "CREATE OR REPLACE TEMP TABLE tmp AS SELECT 1",
+			want:  Attrs{KeyDBOperation: OperationDDL, KeyDBTable: "tmp"},
+		},
+		{
+			query: "CREATE UNIQUE INDEX CONCURRENTLY idx ON users (name)",
+			want:  Attrs{KeyDBOperation: OperationDDL, KeyDBTable: "users"},
+		},
+		{
+			query: "ALTER TABLE ONLY users ADD COLUMN age int",
+			want:  Attrs{KeyDBOperation: OperationDDL, KeyDBTable: "users"},
+		},
+		{
+			query: "DROP TABLE IF EXISTS users",
+			want:  Attrs{KeyDBOperation: OperationDDL, KeyDBTable: "users"},
+		},
+		{
+			query: "TRUNCATE TABLE users",
+			want:  Attrs{KeyDBOperation: OperationDDL, KeyDBTable: "users"},
+		},
+		{
+			query: "TRUNCATE users",
+			want:  Attrs{KeyDBOperation: OperationDDL, KeyDBTable: "users"},
+		},
+		{
+			query: "CREATE VIEW active AS SELECT * FROM users",
+			want:  Attrs{KeyDBOperation: OperationDDL},
+		},
+	}
+
+	prov := NewOperationProvider()
+	for _, cs := range cases {
+		t.Run(cs.query, func(t *testing.T) {
+			got := prov.GetQueryAttrs(context.Background(), cs.query)
+			if !reflect.DeepEqual(cs.want, got) {
+				t.Errorf("got '%v', want '%v'", got, cs.want)
+			}
+		})
+	}
+}
+
+func TestOperationProviderComment(t *testing.T) {
+	got := Comment(context.Background(), "SELECT * FROM users",
+		WithAttrPairs("key", "value"),
+		WithQueryAttrProvider(NewOperationProvider()),
+	)
+	if want := "SELECT * FROM users /*db_operation='SELECT',db_table='users',key='value'*/"; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func TestOperationProviderCommentDialect(t *testing.T) {
+	got := Comment(context.Background(), `SELECT 'it\'s' FROM users`,
+		WithDialect(DialectMySQL),
+		WithQueryAttrProvider(NewOperationProvider()),
+	)
+	if want := `SELECT 'it\'s' FROM users /*db_operation='SELECT',db_table='users'*/`; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func TestOperationProviderDialect(t *testing.T) {
+	cases := []struct {
+		query   string
+		dialect Dialect
+		want    Attrs
+	}{
+		{
+			query:   "SELECT 'it\\'s' FROM users",
+			dialect: DialectMySQL,
+			want:    Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+		{
+			query:   "SELECT \"a\\\" FROM b\" FROM users",
+			dialect: DialectMySQL,
+			want:    Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+		{
+			query:   "# FROM fake\nSELECT 1 FROM users",
+			dialect: DialectMySQL,
+			want:    Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+		{
+			query:   "SELECT 1--1 FROM users",
+			dialect: DialectMySQL,
+			want:    Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+		{
+			query:   "SELECT 1--1 FROM users",
+			dialect: DialectGeneric,
+			want:    Attrs{KeyDBOperation: OperationSelect},
+		},
+		{
+			query:   "SELECT E'it\\'s' FROM users",
+			dialect: DialectPostgres,
+			want:    Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+		{
+			query:   "/* outer /* FROM fake */ */ SELECT 1 FROM users",
+			dialect: DialectPostgres,
+			want:    Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+		{
+			query:   "SELECT q'[it's]' FROM users",
+			dialect: DialectOracle,
+			want:    Attrs{KeyDBOperation: OperationSelect, KeyDBTable: "users"},
+		},
+	}
+
+	prov := NewOperationProvider()
+	for _, cs := range cases {
+		t.Run(cs.query, func(t *testing.T) {
+			got := prov.getDialectQueryAttrs(context.Background(), cs.query, cs.dialect)
+			if !reflect.DeepEqual(cs.want, got) {
+				t.Errorf("got '%v', want '%v'", got, cs.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,93 @@
 package sqlcommenter
 
 import (
+	"bytes"
 	"context"
+	"regexp"
 )
 
 // Option configures commenter.
 type Option func(cmt *commenter)
 
+// PrepareMode controls commenting of prepared statements.
+type PrepareMode int
+
+const (
+	// PrepareComment comments queries when they are prepared.
+	PrepareComment PrepareMode = iota
+	// PrepareSkip leaves prepared queries untouched.
+	PrepareSkip
+)
+
+// TxMode controls commenting of transaction statements.
+type TxMode int
+
+const (
+	// TxDriver leaves transaction handling to driver, transaction statements are not commented.
+	TxDriver TxMode = iota
+	// TxStatements issues commented BEGIN, COMMIT and ROLLBACK statements of configured Dialect
+	// using driver.ExecerContext. Transaction options are added as attrs, non-default options
+	// use START TRANSACTION syntax. Drivers which do not implement driver.ExecerContext or track
+	// transaction state on their own are not supported. Oracle dialect and options not supported
+	// by START TRANSACTION of dialect fall back to TxDriver.
+	TxStatements
+)
+
+// ExistingCommentMode controls commenting of queries which already contain a comment.
+type ExistingCommentMode int
+
+const (
+	// ExistingCommentAppend appends comment after existing comments. It is the default mode.
+	ExistingCommentAppend ExistingCommentMode = iota
+	// ExistingCommentSkip leaves queries with comments untouched.
+	ExistingCommentSkip
+	// ExistingCommentMerge merges attrs into existing sqlcommenter comment at the position of PositionMode,
+	// trailing comment by default and leading comment with PositionPrefix, keeping its values for duplicate keys.
+	// Other queries are handled as in ExistingCommentAppend.
+	ExistingCommentMerge
+)
+
+// PositionMode controls placement of comment in query.
+type PositionMode int
+
+const (
+	// PositionSuffix places comment at the end of query, before trailing semicolons.
+	PositionSuffix PositionMode = iota
+	// PositionPrefix places comment at the beginning of query.
+	PositionPrefix
+)
+
+// MergeMode controls resolution of conflicting keys provided by multiple providers.
+// Attrs stored in context by ContextWithAttrs are merged after all providers.
+type MergeMode int
+
+const (
+	// MergeLastWins keeps value of the last provider.
+	MergeLastWins MergeMode = iota
+	// MergeFirstWins keeps value of the first provider.
+	MergeFirstWins
+	// MergeDropConflicts drops keys with conflicting values.
+	MergeDropConflicts
+)
+
+// ConflictFunc is called when providers return different values for the same key.
+type ConflictFunc func(key string, current string, next string)
+
+// RedactMode controls how are values matching redaction pattern replaced.
+type RedactMode int
+
+const (
+	// RedactReplace replaces value with RedactedValue.
+	RedactReplace RedactMode = iota
+	// RedactHash replaces value with truncated SHA-256 hash of value, or with truncated HMAC-SHA256
+	// when WithRedactionKey is used. Unkeyed hash of low-entropy values like emails, phone numbers
+	// or IDs can be reversed by hashing candidate values, configure WithRedactionKey for them.
+	RedactHash
+)
+
+// TruncateFunc is called when value of key is truncated or key is dropped to respect length limits.
+type TruncateFunc func(key string, dropped bool)
+
 // AttrProvider provides Attrs from context.Context.
 type AttrProvider interface {
 	GetAttrs(context.Context) Attrs
@@ -20,24 +101,43 @@ func (f AttrProviderFunc) GetAttrs(ctx context.Context) Attrs {
 	return f(ctx)
 }
 
+// QueryAttrProvider provides Attrs computed from query.
+type QueryAttrProvider interface {
+	GetQueryAttrs(ctx context.Context, query string) Attrs
+}
+
+// QueryAttrProviderFunc adapts func to QueryAttrProvider.
+type QueryAttrProviderFunc func(ctx context.Context, query string) Attrs
+
+// GetQueryAttrs returns Attrs.
+func (f QueryAttrProviderFunc) GetQueryAttrs(ctx context.Context, query string) Attrs {
+	return f(ctx, query)
+}
+
 // WithAttrs configures commenter with Attrs.
+// Attrs are copied and escaped once, later changes of attrs are not reflected.
 func WithAttrs(attrs Attrs) Option {
+	static := newStaticAttrs(attrs)
 	return func(cmt *commenter) {
-		cmt.providers = append(cmt.providers, AttrProviderFunc(func(ctx context.Context) Attrs {
-			return attrs
-		}))
+		cmt.providers = append(cmt.providers, static)
 	}
 }
 
 // WithAttrPairs configures commenter with attr pairs.
+// Pairs are escaped once, it panics when given odd number of pairs.
 func WithAttrPairs(pairs ...string) Option {
+	static := newStaticAttrs(AttrPairs(pairs...))
 	return func(cmt *commenter) {
-		cmt.providers = append(cmt.providers, AttrProviderFunc(func(ctx context.Context) Attrs {
-			return AttrPairs(pairs...)
-		}))
+		cmt.providers = append(cmt.providers, static)
 	}
 }
 
+// WithStaticAttrProvider configures commenter with AttrProvider returning the same Attrs for every query.
+// Attrs are obtained and escaped once with context.Background when the option is created.
+func WithStaticAttrProvider(prov AttrProvider) Option {
+	return WithAttrs(prov.GetAttrs(context.Background()))
+}
+
 // WithAttrProvider configures commenter with AttrProvider.
 func WithAttrProvider(prov AttrProvider) Option {
 	return func(cmt *commenter) {
@@ -51,3 +151,224 @@ func WithAttrFunc(fn AttrProviderFunc) Option {
 		cmt.providers = append(cmt.providers, fn)
 	}
 }
+
+// WithAttrAppender configures commenter with AttrAppender.
+func WithAttrAppender(app AttrAppender) Option {
+	return func(cmt *commenter) {
+		cmt.providers = append(cmt.providers, attrAppender{app: app})
+	}
+}
+
+// WithQueryAttrProvider configures commenter with QueryAttrProvider.
+// It is merged together with other providers in the order of options.
+func WithQueryAttrProvider(prov QueryAttrProvider) Option {
+	return func(cmt *commenter) {
+		cmt.providers = append(cmt.providers, queryAttrProvider{prov: prov})
+	}
+}
+
+// WithExistingCommentMode configures commenter with ExistingCommentMode.
+func WithExistingCommentMode(mode ExistingCommentMode) Option {
+	return func(cmt *commenter) {
+		cmt.existingMode = mode
+	}
+}
+
+// WithPositionMode configures commenter with PositionMode.
+func WithPositionMode(mode PositionMode) Option {
+	return func(cmt *commenter) {
+		cmt.positionMode = mode
+	}
+}
+
+// WithDialect configures commenter with Dialect.
+// WrapDriver and WrapConnector detect dialect by DetectDialect unless it is configured.
+func WithDialect(d Dialect) Option {
+	return func(cmt *commenter) {
+		cmt.dialect = d
+		cmt.dialectSet = true
+	}
+}
+
+// WithMergeMode configures commenter with MergeMode.
+func WithMergeMode(mode MergeMode) Option {
+	return func(cmt *commenter) {
+		cmt.mergeMode = mode
+	}
+}
+
+// WithPinnedKeys configures commenter to always keep value of the first provider for keys,
+// regardless of MergeMode.
+func WithPinnedKeys(keys ...string) Option {
+	return func(cmt *commenter) {
+		cmt.pinnedKeys = addKeys(cmt.pinnedKeys, keys)
+	}
+}
+
+// WithConflictFunc configures commenter with ConflictFunc.
+func WithConflictFunc(fn ConflictFunc) Option {
+	return func(cmt *commenter) {
+		cmt.conflictFunc = fn
+	}
+}
+
+// WithAllowedKeys configures commenter to emit only provided keys.
+func WithAllowedKeys(keys ...string) Option {
+	return func(cmt *commenter) {
+		cmt.allowedKeys = addKeys(cmt.allowedKeys, keys)
+	}
+}
+
+// WithDeniedKeys configures commenter to never emit provided keys.
+func WithDeniedKeys(keys ...string) Option {
+	return func(cmt *commenter) {
+		cmt.deniedKeys = addKeys(cmt.deniedKeys, keys)
+	}
+}
+
+// WithRedaction configures commenter to redact values matching pattern using RedactMode.
+// Redactions are applied in order, the first matching one wins.
+func WithRedaction(pattern *regexp.Regexp, mode RedactMode) Option {
+	return func(cmt *commenter) {
+		cmt.redactions = append(cmt.redactions, redaction{
+			pattern: pattern,
+			mode:    mode,
+		})
+	}
+}
+
+// WithRedactionKey configures commenter to hash values redacted with RedactHash using HMAC-SHA256 keyed by key.
+// Hashes stay stable for the same key, so keep it secret and share it only with systems correlating them.
+func WithRedactionKey(key []byte) Option {
+	return func(cmt *commenter) {
+		cmt.redactionKey = bytes.Clone(key)
+	}
+}
+
+// WithMaxValueLength configures commenter to truncate values longer than max escaped bytes.
+func WithMaxValueLength(max int) Option {
+	return func(cmt *commenter) {
+		cmt.maxValueLen = max
+	}
+}
+
+// WithMaxCommentLength configures commenter to drop keys until comment fits into max bytes.
+// Keys not configured using WithKeyPriority are dropped first, starting from the last encoded key.
+func WithMaxCommentLength(max int) Option {
+	return func(cmt *commenter) {
+		cmt.maxCommentLen = max
+	}
+}
+
+// WithKeyPriority configures commenter with keys ordered from the highest priority.
+// Keys with lower priority are dropped first when comment exceeds maximum length.
+func WithKeyPriority(keys ...string) Option {
+	return func(cmt *commenter) {
+		cmt.keyPriority = make(map[string]int, len(keys))
+		for i, key := range keys {
+			cmt.keyPriority[key] = len(keys) - i
+		}
+	}
+}
+
+// WithTruncateFunc configures commenter with TruncateFunc.
+func WithTruncateFunc(fn TruncateFunc) Option {
+	return func(cmt *commenter) {
+		cmt.truncateFunc = fn
+	}
+}
+
+// WithSampler configures commenter to comment only queries sampled by Sampler.
+func WithSampler(s Sampler) Option {
+	return func(cmt *commenter) {
+		cmt.sampler = s
+	}
+}
+
+// WithAlwaysComment configures commenter to comment queries matching pred regardless of Sampler.
+func WithAlwaysComment(pred QueryPredicate) Option {
+	return func(cmt *commenter) {
+		cmt.alwaysComment = append(cmt.alwaysComment, pred)
+	}
+}
+
+// WithVolatileKeys configures commenter to exclude keys with per-request values from comment,
+// keeping query text stable for statement caches and query digests.
+// Volatile attrs are passed using statement configured by WithVolatileStatement, otherwise they are omitted.
+func WithVolatileKeys(keys ...string) Option {
+	return func(cmt *commenter) {
+		cmt.volatileKeys = addKeys(cmt.volatileKeys, keys)
+	}
+}
+
+// WithVolatileStatement configures commenter to execute statement built from volatile attrs
+// on connection before query, for example SetStatement("application_name").
+// Statement is executed only when it differs from the last one executed on connection
+// and only for drivers implementing driver.ExecerContext.
+// Volatile attrs are limited by WithMaxValueLength and WithMaxCommentLength separately from comment.
+// Prepared statements send volatile attrs of the context they are executed with.
+// Statement is executed again after transaction or savepoint is rolled back, as database may revert it.
+// Failed statement is ignored inside transaction, so that aborted transaction can still be rolled back.
+func WithVolatileStatement(fn StatementFunc) Option {
+	return func(cmt *commenter) {
+		cmt.volatileStmt = fn
+	}
+}
+
+// WithSessionStatement configures commenter to execute statement built from Attrs provided by prov
+// when connection is opened and when its session is reset before reuse,
+// for example SetStatement("application_name").
+// Provider receives context.Context of the connect or reset call.
+// database/sql resets session every time connection is taken from pool, statements are executed
+// on reset only when any of them differs from statements executed last time on connection,
+// so provider should return values changing rarely. Drivers discarding session state
+// in their own ResetSession are not supported.
+// Statements are executed only for drivers implementing driver.ExecerContext.
+func WithSessionStatement(fn StatementFunc, prov AttrProvider) Option {
+	return func(cmt *commenter) {
+		cmt.sessionStmts = append(cmt.sessionStmts, sessionStatement{
+			fn:   fn,
+			prov: prov,
+		})
+	}
+}
+
+// WithSkip configures commenter to leave queries matching pred untouched, for example SkipPrefixes("LISTEN", "COPY").
+func WithSkip(pred QueryPredicate) Option {
+	return func(cmt *commenter) {
+		cmt.skip = append(cmt.skip, pred)
+	}
+}
+
+// WithQueryCache configures commenter to cache commented queries in cache,
+// repeated queries with the same attrs return cached string without allocating.
+// It pays off when application issues limited set of queries with limited set of attr values.
+func WithQueryCache(cache *QueryCache) Option {
+	return func(cmt *commenter) {
+		cmt.cache = cache
+	}
+}
+
+// WithTxMode configures commenter with TxMode.
+func WithTxMode(mode TxMode) Option {
+	return func(cmt *commenter) {
+		cmt.txMode = mode
+	}
+}
+
+// WithPrepareMode configures commenter with PrepareMode.
+func WithPrepareMode(mode PrepareMode) Option {
+	return func(cmt *commenter) {
+		cmt.prepareMode = mode
+	}
+}
+
+func addKeys(set map[string]struct{}, keys []string) map[string]struct{} {
+	if set == nil {
+		set = make(map[string]struct{}, len(keys))
+	}
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+	return set
+}
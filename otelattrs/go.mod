@@ -0,0 +1,23 @@
+module github.com/jbub/sqlcommenter/otelattrs
+
+go 1.23.0
+
+// Replace is ignored by importers, released module requires sqlcommenter release
+// containing API used by this module, tag sqlcommenter before tagging this module.
+replace github.com/jbub/sqlcommenter => ../
+
+require (
+	github.com/jbub/sqlcommenter v0.5.0
+	go.opentelemetry.io/otel/sdk v1.38.0
+	go.opentelemetry.io/otel/trace v1.38.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel v1.38.0 // indirect
+	go.opentelemetry.io/otel/metric v1.38.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
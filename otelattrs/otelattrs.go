@@ -0,0 +1,65 @@
+// Package otelattrs provides sqlcommenter.AttrProvider propagating OpenTelemetry trace context.
+package otelattrs
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jbub/sqlcommenter"
+)
+
+const (
+	// KeyTraceparent is the attribute key of W3C traceparent.
+	KeyTraceparent = "traceparent"
+	// KeyTracestate is the attribute key of W3C tracestate.
+	KeyTracestate = "tracestate"
+)
+
+// traceparentVersion is the only supported version of W3C traceparent format.
+const traceparentVersion = "00"
+
+var _ sqlcommenter.AttrProvider = (*Provider)(nil)
+
+// Option configures Provider.
+type Option func(p *Provider)
+
+// WithSampledOnly configures Provider to emit attrs only for sampled spans.
+func WithSampledOnly() Option {
+	return func(p *Provider) {
+		p.sampledOnly = true
+	}
+}
+
+// NewProvider returns new Provider configured with provided options.
+func NewProvider(opts ...Option) *Provider {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Provider provides traceparent and tracestate Attrs from span found in context.Context.
+type Provider struct {
+	sampledOnly bool
+}
+
+// GetAttrs returns Attrs.
+func (p *Provider) GetAttrs(ctx context.Context) sqlcommenter.Attrs {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	if p.sampledOnly && !sc.IsSampled() {
+		return nil
+	}
+
+	attrs := sqlcommenter.Attrs{
+		KeyTraceparent: traceparentVersion + "-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-" + sc.TraceFlags().String(),
+	}
+	if ts := sc.TraceState(); ts.Len() > 0 {
+		attrs[KeyTracestate] = ts.String()
+	}
+	return attrs
+}
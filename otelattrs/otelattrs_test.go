@@ -0,0 +1,128 @@
+package otelattrs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jbub/sqlcommenter"
+)
+
+func TestProvider(t *testing.T) {
+	traceState, err := trace.ParseTraceState("vendor=value,other=1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x5b, 0xd6, 0x6e, 0xf5, 0x09, 0x53, 0x69, 0xc7, 0xb0, 0xd1, 0xf8, 0xf4, 0xbd, 0x33, 0x71, 0x6a},
+		SpanID:     trace.SpanID{0xc5, 0x32, 0xcb, 0x40, 0x98, 0xac, 0x3d, 0xd2},
+		TraceFlags: trace.FlagsSampled,
+		TraceState: traceState,
+		Remote:     true,
+	})
+
+	cases := []struct {
+		name    string
+		sampler sdktrace.Sampler
+		parent  trace.SpanContext
+		opts    []Option
+		want    func(span trace.SpanContext) sqlcommenter.Attrs
+	}{
+		{
+			name:    "sampled span",
+			sampler: sdktrace.AlwaysSample(),
+			want: func(span trace.SpanContext) sqlcommenter.Attrs {
+				return sqlcommenter.Attrs{
+					KeyTraceparent: "00-" + span.TraceID().String() + "-" + span.SpanID().String() + "-01",
+				}
+			},
+		},
+		{
+			name:    "not sampled span",
+			sampler: sdktrace.NeverSample(),
+			want: func(span trace.SpanContext) sqlcommenter.Attrs {
+				return sqlcommenter.Attrs{
+					KeyTraceparent: "00-" + span.TraceID().String() + "-" + span.SpanID().String() + "-00",
+				}
+			},
+		},
+		{
+			name:    "not sampled span sampled only",
+			sampler: sdktrace.NeverSample(),
+			opts:    []Option{WithSampledOnly()},
+			want: func(span trace.SpanContext) sqlcommenter.Attrs {
+				return nil
+			},
+		},
+		{
+			name:    "sampled span sampled only",
+			sampler: sdktrace.AlwaysSample(),
+			opts:    []Option{WithSampledOnly()},
+			want: func(span trace.SpanContext) sqlcommenter.Attrs {
+				return sqlcommenter.Attrs{
+					KeyTraceparent: "00-" + span.TraceID().String() + "-" + span.SpanID().String() + "-01",
+				}
+			},
+		},
+		{
+			name:    "span with remote parent and trace state",
+			sampler: sdktrace.ParentBased(sdktrace.NeverSample()),
+			parent:  remote,
+			want: func(span trace.SpanContext) sqlcommenter.Attrs {
+				return sqlcommenter.Attrs{
+					KeyTraceparent: "00-5bd66ef5095369c7b0d1f8f4bd33716a-" + span.SpanID().String() + "-01",
+					KeyTracestate:  "vendor=value,other=1",
+				}
+			},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(
+				sdktrace.WithSampler(cs.sampler),
+				sdktrace.WithSpanProcessor(recorder),
+			)
+			defer func() { _ = tp.Shutdown(context.Background()) }()
+
+			ctx := context.Background()
+			if cs.parent.IsValid() {
+				ctx = trace.ContextWithRemoteSpanContext(ctx, cs.parent)
+			}
+			ctx, span := tp.Tracer("test").Start(ctx, "query")
+			defer span.End()
+
+			got := NewProvider(cs.opts...).GetAttrs(ctx)
+			if want := cs.want(span.SpanContext()); !reflect.DeepEqual(want, got) {
+				t.Errorf("got '%v', want '%v'", got, want)
+			}
+		})
+	}
+}
+
+func TestProviderNoSpan(t *testing.T) {
+	if got := NewProvider().GetAttrs(context.Background()); got != nil {
+		t.Errorf("got '%v', want '%v'", got, nil)
+	}
+}
+
+func TestProviderComment(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "query")
+	defer span.End()
+
+	sc := span.SpanContext()
+	got := sqlcommenter.Comment(ctx, "SELECT 1", sqlcommenter.WithAttrProvider(NewProvider()))
+	want := "SELECT 1 /*traceparent='00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-01'*/"
+	if got != want {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
@@ -0,0 +1,24 @@
+package otelattrs
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jbub/sqlcommenter"
+)
+
+// NewSampler returns sqlcommenter.Sampler commenting fraction of queries given by rate in range [0, 1],
+// deciding deterministically by trace ID of span found in context.Context, so that all queries
+// of a single trace are either commented or not. Queries without span are sampled randomly.
+func NewSampler(rate float64) sqlcommenter.Sampler {
+	return sqlcommenter.KeySampler(rate, traceIDKey)
+}
+
+func traceIDKey(ctx context.Context) (string, bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return "", false
+	}
+	return sc.TraceID().String(), true
+}
@@ -0,0 +1,38 @@
+package otelattrs
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestSampler(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	smp := NewSampler(0.5)
+	tracer := tp.Tracer("test")
+
+	var got int
+	for i := 0; i < 1000; i++ {
+		ctx, span := tracer.Start(context.Background(), "request")
+		want := smp.Sample(ctx, "SELECT 1")
+
+		for j := 0; j < 5; j++ {
+			childCtx, child := tracer.Start(ctx, "query")
+			if smp.Sample(childCtx, "SELECT 2") != want {
+				t.Fatalf("got different decision for trace '%v'", span.SpanContext().TraceID())
+			}
+			child.End()
+		}
+		span.End()
+
+		if want {
+			got++
+		}
+	}
+	if got < 400 || got > 600 {
+		t.Errorf("got '%v', want between '%v' and '%v'", got, 400, 600)
+	}
+}
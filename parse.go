@@ -0,0 +1,94 @@
+package sqlcommenter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMalformedComment is returned by Parse when comment is not in sqlcommenter format.
+var ErrMalformedComment = errors.New("sqlcommenter: malformed comment")
+
+// Parse extracts Attrs from comment placed at the end or at the beginning of query.
+// It returns query stripped of the comment together with decoded Attrs.
+// If query does not contain such comment, it is returned unchanged with nil Attrs.
+// Leading comment is tried also when trailing comment is not in sqlcommenter format.
+// Query is scanned using DialectGeneric, use Dialect.Parse for queries using syntax of other dialects.
+func Parse(query string) (string, Attrs, error) {
+	return DialectGeneric.Parse(query)
+}
+
+// Parse extracts Attrs from comment like Parse, scanning query according to dialect d.
+// Hints preceding the leading comment are kept in query.
+func (d Dialect) Parse(query string) (string, Attrs, error) {
+	var trailingErr error
+	body, trailer := d.splitTrailer(query)
+	if span, ok := d.trailingComment(body); ok {
+		if content, ok := span.blockBody(query); ok {
+			attrs, err := parseAttrs(content)
+			if err == nil {
+				return trimRightSpace(body[:span.start]) + strings.TrimSpace(trailer), attrs, nil
+			}
+			trailingErr = err
+		}
+	}
+
+	if span, ok := d.leadingComment(query); ok {
+		if content, ok := span.blockBody(query); ok {
+			attrs, err := parseAttrs(content)
+			if err != nil {
+				return query, nil, err
+			}
+			return query[:span.start] + trimLeftSpace(query[span.end:]), attrs, nil
+		}
+	}
+	return query, nil, trailingErr
+}
+
+func parseAttrs(s string) (Attrs, error) {
+	attrs := make(Attrs)
+	if s == "" {
+		return attrs, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		idx := strings.IndexByte(pair, '=')
+		if idx == -1 {
+			return nil, fmt.Errorf("%w: missing '=' in %q", ErrMalformedComment, pair)
+		}
+
+		rawKey, rawValue := pair[:idx], pair[idx+1:]
+		if len(rawValue) < 2 || rawValue[0] != '\'' || rawValue[len(rawValue)-1] != '\'' {
+			return nil, fmt.Errorf("%w: unquoted value in %q", ErrMalformedComment, pair)
+		}
+		rawValue = rawValue[1 : len(rawValue)-1]
+		if !isEscaped(rawKey, true) || !isEscaped(rawValue, false) {
+			return nil, fmt.Errorf("%w: unescaped character in %q", ErrMalformedComment, pair)
+		}
+
+		key, err := queryUnescape(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedComment, err)
+		}
+		value, err := pathUnescape(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedComment, err)
+		}
+		attrs[key] = value
+	}
+	return attrs, nil
+}
+
+// isEscaped reports whether s contains only characters left unescaped by encoder.
+func isEscaped(s string, query bool) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' || (c == '+' && query) {
+			continue
+		}
+		if shouldEscape(c, query) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,254 @@
+package sqlcommenter
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name      string
+		query     string
+		wantQuery string
+		wantAttrs Attrs
+		wantErr   error
+	}{
+		{
+			name: "empty query",
+		},
+		{
+			name:      "query without comment",
+			query:     "SELECT 1",
+			wantQuery: "SELECT 1",
+		},
+		{
+			name:      "query with empty comment",
+			query:     "SELECT 1 /**/",
+			wantQuery: "SELECT 1",
+			wantAttrs: Attrs{},
+		},
+		{
+			name:      "query with single attr",
+			query:     "SELECT 1 /*key='value'*/",
+			wantQuery: "SELECT 1",
+			wantAttrs: Attrs{"key": "value"},
+		},
+		{
+			name:      "query with multiple attrs",
+			query:     "SELECT 1 /*2key='%2Fparam%20first',key='DROP%20TABLE%20FOO',name='1234'*/",
+			wantQuery: "SELECT 1",
+			wantAttrs: Attrs{"2key": "/param first", "key": "DROP TABLE FOO", "name": "1234"},
+		},
+		{
+			name:      "query with escaped key",
+			query:     "SELECT 1 /*my+key%3D='value'*/",
+			wantQuery: "SELECT 1",
+			wantAttrs: Attrs{"my key=": "value"},
+		},
+		{
+			name:      "query with trailing whitespace",
+			query:     "SELECT 1 /*key='value'*/ \n",
+			wantQuery: "SELECT 1",
+			wantAttrs: Attrs{"key": "value"},
+		},
+		{
+			name:      "query with trailing semicolon",
+			query:     "SELECT 1 /*key='value'*/;",
+			wantQuery: "SELECT 1;",
+			wantAttrs: Attrs{"key": "value"},
+		},
+		{
+			name:      "query with prefix comment",
+			query:     "/*key='value'*/ SELECT 1",
+			wantQuery: "SELECT 1",
+			wantAttrs: Attrs{"key": "value"},
+		},
+		{
+			name:      "query with unterminated comment",
+			query:     "SELECT 1 /*key='value'",
+			wantQuery: "SELECT 1 /*key='value'",
+		},
+		{
+			name:      "query with unquoted value",
+			query:     "SELECT 1 /*key=value*/",
+			wantQuery: "SELECT 1 /*key=value*/",
+			wantErr:   ErrMalformedComment,
+		},
+		{
+			name:      "query with non sqlcommenter comment",
+			query:     "SELECT 1 /* comment */",
+			wantQuery: "SELECT 1 /* comment */",
+			wantErr:   ErrMalformedComment,
+		},
+		{
+			name:      "query with prefix comment and non sqlcommenter comment",
+			query:     "/*a='1'*/ SELECT 1 /* note */",
+			wantQuery: "SELECT 1 /* note */",
+			wantAttrs: Attrs{"a": "1"},
+		},
+		{
+			name:      "query with comment delimiter in string",
+			query:     "SELECT 'it\\'s /*' /*a='b'*/",
+			wantQuery: "SELECT 'it\\'s /*' /*a='b'*/",
+			wantErr:   ErrMalformedComment,
+		},
+		{
+			name:      "query with unescaped space",
+			query:     "SELECT 1 /*a b='c'*/",
+			wantQuery: "SELECT 1 /*a b='c'*/",
+			wantErr:   ErrMalformedComment,
+		},
+		{
+			name:      "query with unescaped quote in value",
+			query:     "SELECT 1 /*a='b'c'*/",
+			wantQuery: "SELECT 1 /*a='b'c'*/",
+			wantErr:   ErrMalformedComment,
+		},
+		{
+			name:      "query with invalid escape",
+			query:     "SELECT 1 /*key='%2'*/",
+			wantQuery: "SELECT 1 /*key='%2'*/",
+			wantErr:   ErrMalformedComment,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			gotQuery, gotAttrs, err := Parse(cs.query)
+			if !errors.Is(err, cs.wantErr) {
+				t.Fatalf("got error '%v', want '%v'", err, cs.wantErr)
+			}
+			if gotQuery != cs.wantQuery {
+				t.Errorf("got '%v', want '%v'", gotQuery, cs.wantQuery)
+			}
+			if !reflect.DeepEqual(gotAttrs, cs.wantAttrs) {
+				t.Errorf("got '%v', want '%v'", gotAttrs, cs.wantAttrs)
+			}
+		})
+	}
+}
+
+func TestDialectParse(t *testing.T) {
+	cases := []struct {
+		name      string
+		dialect   Dialect
+		query     string
+		wantQuery string
+		wantAttrs Attrs
+		wantErr   error
+	}{
+		{
+			name:      "postgres prefix comment after hint",
+			dialect:   DialectPostgres,
+			query:     "/*+ SeqScan(t) */ /*k='v'*/ SELECT 1",
+			wantQuery: "/*+ SeqScan(t) */ SELECT 1",
+			wantAttrs: Attrs{"k": "v"},
+		},
+		{
+			name:      "postgres suffix comment with hint",
+			dialect:   DialectPostgres,
+			query:     "/*+ SeqScan(t) */ SELECT 1 /*k='v'*/",
+			wantQuery: "/*+ SeqScan(t) */ SELECT 1",
+			wantAttrs: Attrs{"k": "v"},
+		},
+		{
+			name:      "generic prefix comment after hint",
+			dialect:   DialectGeneric,
+			query:     "/*+ SeqScan(t) */ /*k='v'*/ SELECT 1",
+			wantQuery: "/*+ SeqScan(t) */ /*k='v'*/ SELECT 1",
+			wantErr:   ErrMalformedComment,
+		},
+		{
+			name:      "mysql backslash escape",
+			dialect:   DialectMySQL,
+			query:     "SELECT 'it\\'s /*' /*a='b'*/",
+			wantQuery: "SELECT 'it\\'s /*'",
+			wantAttrs: Attrs{"a": "b"},
+		},
+		{
+			name:      "mysql hash comment",
+			dialect:   DialectMySQL,
+			query:     "SELECT 1 # note /*a='b'*/",
+			wantQuery: "SELECT 1 # note /*a='b'*/",
+		},
+		{
+			name:      "mysql prefix comment after hint",
+			dialect:   DialectMySQL,
+			query:     "/*+ BKA(t) */ /*a='b'*/ SELECT 1",
+			wantQuery: "/*+ BKA(t) */ SELECT 1",
+			wantAttrs: Attrs{"a": "b"},
+		},
+		{
+			name:      "sqlite bracket identifier",
+			dialect:   DialectSQLite,
+			query:     "SELECT [a/*b] FROM t /*a='b'*/",
+			wantQuery: "SELECT [a/*b] FROM t",
+			wantAttrs: Attrs{"a": "b"},
+		},
+		{
+			name:      "sqlserver nested comment",
+			dialect:   DialectSQLServer,
+			query:     "SELECT 1 /* outer /* inner */ */ /*a='b'*/",
+			wantQuery: "SELECT 1 /* outer /* inner */ */",
+			wantAttrs: Attrs{"a": "b"},
+		},
+		{
+			name:      "oracle q quote",
+			dialect:   DialectOracle,
+			query:     "SELECT q'[/*]' FROM dual /*a='b'*/",
+			wantQuery: "SELECT q'[/*]' FROM dual",
+			wantAttrs: Attrs{"a": "b"},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			gotQuery, gotAttrs, err := cs.dialect.Parse(cs.query)
+			if !errors.Is(err, cs.wantErr) {
+				t.Fatalf("got error '%v', want '%v'", err, cs.wantErr)
+			}
+			if gotQuery != cs.wantQuery {
+				t.Errorf("got '%v', want '%v'", gotQuery, cs.wantQuery)
+			}
+			if !reflect.DeepEqual(gotAttrs, cs.wantAttrs) {
+				t.Errorf("got '%v', want '%v'", gotAttrs, cs.wantAttrs)
+			}
+		})
+	}
+}
+
+func TestDialectParseCommented(t *testing.T) {
+	cmt := NewCommenter(WithDialect(DialectPostgres), WithPositionMode(PositionPrefix), WithAttrPairs("k", "v"))
+	query := "/*+ SeqScan(t) */ SELECT 1"
+
+	gotQuery, gotAttrs, err := DialectPostgres.Parse(cmt.Comment(context.Background(), query))
+	assertNoError(t, err)
+	if gotQuery != query {
+		t.Errorf("got '%v', want '%v'", gotQuery, query)
+	}
+	if want := (Attrs{"k": "v"}); !reflect.DeepEqual(gotAttrs, want) {
+		t.Errorf("got '%v', want '%v'", gotAttrs, want)
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	attrs := Attrs{
+		"key":         "value",
+		"route":       "/users/{id}",
+		"odd key,=":   "it's 100% 'quoted', /* nested */",
+		"traceparent": "00-5bd66ef5095369c7b0d1f8f4bd33716a-c532cb4098ac3dd2-01",
+	}
+
+	query := Comment(context.Background(), "SELECT 1", WithAttrs(attrs))
+	gotQuery, gotAttrs, err := Parse(query)
+	assertNoError(t, err)
+
+	if gotQuery != "SELECT 1" {
+		t.Errorf("got '%v', want '%v'", gotQuery, "SELECT 1")
+	}
+	if !reflect.DeepEqual(gotAttrs, attrs) {
+		t.Errorf("got '%v', want '%v'", gotAttrs, attrs)
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/jbub/sqlcommenter/pgxcommenter
+
+go 1.23.0
+
+// Replace is ignored by importers, released module requires sqlcommenter release
+// containing API used by this module, tag sqlcommenter before tagging this module.
+replace github.com/jbub/sqlcommenter => ../
+
+require (
+	github.com/jackc/pgx/v5 v5.7.6
+	github.com/jbub/sqlcommenter v0.5.0
+)
+
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	golang.org/x/crypto v0.37.0 // indirect
+	golang.org/x/text v0.24.0 // indirect
+)
@@ -0,0 +1,102 @@
+// Package pgxcommenter provides sqlcommenter support for pgx v5 without database/sql.
+package pgxcommenter
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/jbub/sqlcommenter"
+)
+
+var (
+	_ Querier = (*pgx.Conn)(nil)
+	_ Querier = (pgx.Tx)(nil)
+	_ Querier = (*Conn)(nil)
+)
+
+// Querier is implemented by *pgx.Conn, *pgxpool.Pool, *pgxpool.Conn and pgx.Tx.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// Wrap wraps Querier with sqlcommenter support.
+// Queries without whitespace are passed through untouched, as pgx treats them as prepared statement names.
+// Attrs stored in context by sqlcommenter.ContextWithAttrs are always included.
+// Queries are scanned using sqlcommenter.DialectPostgres unless configured otherwise.
+// Options executing statements on connection, sqlcommenter.WithVolatileStatement, sqlcommenter.WithSessionStatement,
+// sqlcommenter.WithTxMode and sqlcommenter.WithPrepareMode, have no effect, keys configured
+// by sqlcommenter.WithVolatileKeys are only left out of comment.
+func Wrap(q Querier, opts ...sqlcommenter.Option) *Conn {
+	opts = append([]sqlcommenter.Option{sqlcommenter.WithDialect(sqlcommenter.DialectPostgres)}, opts...)
+	return WrapCommenter(q, sqlcommenter.NewCommenter(opts...))
+}
+
+// WrapCommenter wraps Querier using already configured sqlcommenter.Commenter.
+// It allows sharing single Commenter between multiple connections or transactions.
+func WrapCommenter(q Querier, cmt *sqlcommenter.Commenter) *Conn {
+	return &Conn{
+		q:   q,
+		cmt: cmt,
+	}
+}
+
+// Conn comments queries before passing them to wrapped Querier.
+type Conn struct {
+	q   Querier
+	cmt *sqlcommenter.Commenter
+}
+
+// Exec comments sql and calls Exec of wrapped Querier.
+func (c *Conn) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return c.q.Exec(ctx, c.comment(ctx, sql), args...)
+}
+
+// Query comments sql and calls Query of wrapped Querier.
+func (c *Conn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return c.q.Query(ctx, c.comment(ctx, sql), args...)
+}
+
+// QueryRow comments sql and calls QueryRow of wrapped Querier.
+func (c *Conn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return c.q.QueryRow(ctx, c.comment(ctx, sql), args...)
+}
+
+// SendBatch comments all queued queries and calls SendBatch of wrapped Querier.
+// Provided batch is not modified.
+func (c *Conn) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	commented := &pgx.Batch{
+		QueuedQueries: make([]*pgx.QueuedQuery, len(b.QueuedQueries)),
+	}
+	for i, qq := range b.QueuedQueries {
+		cqq := *qq
+		cqq.SQL = c.comment(ctx, qq.SQL)
+		commented.QueuedQueries[i] = &cqq
+	}
+	return c.q.SendBatch(ctx, commented)
+}
+
+// CopyFrom calls CopyFrom of wrapped Querier.
+// COPY statement is built by pgx, so it is passed through without comment.
+func (c *Conn) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return c.q.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// comment comments sql unless it refers to prepared statement by name.
+func (c *Conn) comment(ctx context.Context, sql string) string {
+	if !strings.ContainsAny(sql, " \t\r\n") {
+		return sql
+	}
+	return c.cmt.Comment(ctx, sql)
+}
+
+// Unwrap returns wrapped Querier.
+func (c *Conn) Unwrap() Querier {
+	return c.q
+}
@@ -0,0 +1,348 @@
+package pgxcommenter
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+
+	"github.com/jbub/sqlcommenter"
+)
+
+func TestConn(t *testing.T) {
+	cases := []struct {
+		name    string
+		perform func(ctx context.Context, conn *Conn)
+		want    []string
+	}{
+		{
+			name: "Exec",
+			perform: func(ctx context.Context, conn *Conn) {
+				_, _ = conn.Exec(ctx, "UPDATE users SET name = 'joe'")
+			},
+			want: []string{"UPDATE users SET name = 'joe' /*key='value'*/"},
+		},
+		{
+			name: "Query",
+			perform: func(ctx context.Context, conn *Conn) {
+				_, _ = conn.Query(ctx, "SELECT 1")
+			},
+			want: []string{"SELECT 1 /*key='value'*/"},
+		},
+		{
+			name: "QueryRow",
+			perform: func(ctx context.Context, conn *Conn) {
+				_ = conn.QueryRow(ctx, "SELECT 1")
+			},
+			want: []string{"SELECT 1 /*key='value'*/"},
+		},
+		{
+			name: "Query prepared statement name",
+			perform: func(ctx context.Context, conn *Conn) {
+				_, _ = conn.Query(ctx, "select_user")
+			},
+			want: []string{"select_user"},
+		},
+		{
+			name: "Query attrs from context",
+			perform: func(ctx context.Context, conn *Conn) {
+				ctx = sqlcommenter.ContextWithAttrs(ctx, sqlcommenter.Attrs{"request": "22"})
+				_, _ = conn.Query(ctx, "SELECT 1")
+			},
+			want: []string{"SELECT 1 /*key='value',request='22'*/"},
+		},
+		{
+			name: "Query hint",
+			perform: func(ctx context.Context, conn *Conn) {
+				_, _ = conn.Query(ctx, "/*+ SeqScan(users) */ SELECT * FROM users")
+			},
+			want: []string{"/*+ SeqScan(users) */ SELECT * FROM users /*key='value'*/"},
+		},
+		{
+			name: "SendBatch",
+			perform: func(ctx context.Context, conn *Conn) {
+				b := &pgx.Batch{}
+				b.Queue("SELECT 1")
+				b.Queue("UPDATE users SET name = 'joe'")
+				_ = conn.SendBatch(ctx, b)
+			},
+			want: []string{"SELECT 1 /*key='value'*/", "UPDATE users SET name = 'joe' /*key='value'*/"},
+		},
+		{
+			name: "CopyFrom",
+			perform: func(ctx context.Context, conn *Conn) {
+				_, _ = conn.CopyFrom(ctx, pgx.Identifier{"users"}, []string{"name"}, pgx.CopyFromRows(nil))
+			},
+			want: []string{`COPY "users"`},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			q := &mockQuerier{}
+			conn := Wrap(q, sqlcommenter.WithAttrPairs("key", "value"))
+
+			cs.perform(context.Background(), conn)
+			if !reflect.DeepEqual(q.queries, cs.want) {
+				t.Errorf("got '%v', want '%v'", q.queries, cs.want)
+			}
+		})
+	}
+}
+
+func TestConnSendBatchPreservesBatch(t *testing.T) {
+	q := &mockQuerier{}
+	conn := Wrap(q, sqlcommenter.WithAttrPairs("key", "value"))
+
+	var called bool
+	b := &pgx.Batch{}
+	b.Queue("UPDATE users SET name = $1", "joe").Exec(func(ct pgconn.CommandTag) error {
+		called = true
+		return nil
+	})
+
+	_ = conn.SendBatch(context.Background(), b)
+
+	if got, want := b.QueuedQueries[0].SQL, "UPDATE users SET name = $1"; got != want {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+
+	sent := q.batches[0].QueuedQueries[0]
+	if got, want := sent.Arguments, []any{"joe"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+	if err := sent.Fn(&mockBatchResults{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !called {
+		t.Error("batch callback not called")
+	}
+}
+
+func TestConnPgx(t *testing.T) {
+	cases := []struct {
+		name      string
+		perform   func(t *testing.T, ctx context.Context, conn *Conn)
+		wantSQL   []string
+		wantBinds []string
+	}{
+		{
+			name: "Exec simple protocol",
+			perform: func(t *testing.T, ctx context.Context, conn *Conn) {
+				_, err := conn.Exec(ctx, "UPDATE users SET name = 'joe'")
+				assertNoError(t, err)
+			},
+			wantSQL: []string{"UPDATE users SET name = 'joe' /*key='value'*/"},
+		},
+		{
+			name: "Exec extended protocol",
+			perform: func(t *testing.T, ctx context.Context, conn *Conn) {
+				_, err := conn.Exec(ctx, "UPDATE users SET name = $1", "joe")
+				assertNoError(t, err)
+			},
+			wantSQL: []string{"UPDATE users SET name = $1 /*key='value'*/"},
+		},
+		{
+			name: "Exec prepared statement name",
+			perform: func(t *testing.T, ctx context.Context, conn *Conn) {
+				_, err := conn.Unwrap().(*pgx.Conn).Prepare(ctx, "update_user", "UPDATE users SET name = $1")
+				assertNoError(t, err)
+
+				_, err = conn.Exec(ctx, "update_user", "joe")
+				assertNoError(t, err)
+			},
+			wantSQL:   []string{"UPDATE users SET name = $1"},
+			wantBinds: []string{"update_user"},
+		},
+		{
+			name: "Exec query with whitespace",
+			perform: func(t *testing.T, ctx context.Context, conn *Conn) {
+				_, err := conn.Exec(ctx, "UPDATE\tusers\nSET name = 'joe'")
+				assertNoError(t, err)
+			},
+			wantSQL: []string{"UPDATE\tusers\nSET name = 'joe' /*key='value'*/"},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			ctx := context.Background()
+			srv := &fakeServer{}
+			pgxConn := connectFake(t, ctx, srv)
+			defer pgxConn.Close(ctx)
+
+			cs.perform(t, ctx, Wrap(pgxConn, sqlcommenter.WithAttrPairs("key", "value")))
+
+			gotSQL, gotBinds := srv.received()
+			if !reflect.DeepEqual(gotSQL, cs.wantSQL) {
+				t.Errorf("got '%v', want '%v'", gotSQL, cs.wantSQL)
+			}
+			if !reflect.DeepEqual(gotBinds, cs.wantBinds) {
+				t.Errorf("got '%v', want '%v'", gotBinds, cs.wantBinds)
+			}
+		})
+	}
+}
+
+func TestConnUnwrap(t *testing.T) {
+	q := &mockQuerier{}
+	if got := Wrap(q).Unwrap(); got != q {
+		t.Errorf("got '%v', want '%v'", got, q)
+	}
+}
+
+type mockQuerier struct {
+	queries []string
+	batches []*pgx.Batch
+}
+
+func (m *mockQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	m.queries = append(m.queries, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (m *mockQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	m.queries = append(m.queries, sql)
+	return nil, nil
+}
+
+func (m *mockQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	m.queries = append(m.queries, sql)
+	return nil
+}
+
+func (m *mockQuerier) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	for _, qq := range b.QueuedQueries {
+		m.queries = append(m.queries, qq.SQL)
+	}
+	m.batches = append(m.batches, b)
+	return &mockBatchResults{}
+}
+
+func (m *mockQuerier) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	m.queries = append(m.queries, "COPY "+tableName.Sanitize())
+	return 0, nil
+}
+
+type mockBatchResults struct {
+	pgx.BatchResults
+}
+
+func (m *mockBatchResults) Exec() (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func assertNoError(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// connectFake connects pgx to srv using in-memory connection.
+func connectFake(t *testing.T, ctx context.Context, srv *fakeServer) *pgx.Conn {
+	t.Helper()
+
+	cfg, err := pgx.ParseConfig("postgres://user@127.0.0.1:5432/db?sslmode=disable")
+	assertNoError(t, err)
+	cfg.DialFunc = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go srv.serve(server)
+		return client, nil
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	assertNoError(t, err)
+	return conn
+}
+
+// oidText is OID of text type.
+const oidText = 25
+
+// fakeServer speaks just enough of PostgreSQL protocol to execute statements without result rows,
+// recording SQL received by Query and Parse messages and statement names of Bind messages.
+type fakeServer struct {
+	mu    sync.Mutex
+	sql   []string
+	binds []string
+}
+
+func (s *fakeServer) received() ([]string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sql, s.binds
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	backend := pgproto3.NewBackend(conn, conn)
+	if _, err := backend.ReceiveStartupMessage(); err != nil {
+		return
+	}
+	backend.Send(&pgproto3.AuthenticationOk{})
+	backend.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: 1})
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if err := backend.Flush(); err != nil {
+		return
+	}
+
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.Query:
+			s.record(&s.sql, msg.String)
+			backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("UPDATE 1")})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		case *pgproto3.Parse:
+			s.record(&s.sql, msg.Query)
+			backend.Send(&pgproto3.ParseComplete{})
+		case *pgproto3.Describe:
+			if msg.ObjectType == 'S' {
+				var params []uint32
+				for i := 0; i < strings.Count(s.lastSQL(), "$"); i++ {
+					params = append(params, oidText)
+				}
+				backend.Send(&pgproto3.ParameterDescription{ParameterOIDs: params})
+			}
+			backend.Send(&pgproto3.NoData{})
+		case *pgproto3.Bind:
+			if !strings.HasPrefix(msg.PreparedStatement, "stmtcache_") {
+				s.record(&s.binds, msg.PreparedStatement)
+			}
+			backend.Send(&pgproto3.BindComplete{})
+		case *pgproto3.Execute:
+			backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("UPDATE 1")})
+		case *pgproto3.Sync:
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		case *pgproto3.Terminate:
+			return
+		}
+		if err := backend.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) record(dst *[]string, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*dst = append(*dst, value)
+}
+
+func (s *fakeServer) lastSQL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sql[len(s.sql)-1]
+}
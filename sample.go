@@ -0,0 +1,77 @@
+package sqlcommenter
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+)
+
+// Sampler decides whether query is commented.
+type Sampler interface {
+	Sample(ctx context.Context, query string) bool
+}
+
+// SamplerFunc adapts func to Sampler.
+type SamplerFunc func(ctx context.Context, query string) bool
+
+// Sample reports whether query is commented.
+func (f SamplerFunc) Sample(ctx context.Context, query string) bool {
+	return f(ctx, query)
+}
+
+// QueryPredicate reports whether query matches.
+type QueryPredicate func(ctx context.Context, query string) bool
+
+// KeyFunc returns sampling key from context.Context, reporting false if there is none.
+type KeyFunc func(ctx context.Context) (string, bool)
+
+// RateSampler returns Sampler commenting random fraction of queries given by rate in range [0, 1].
+func RateSampler(rate float64) Sampler {
+	return SamplerFunc(func(ctx context.Context, query string) bool {
+		return sampleRandom(rate)
+	})
+}
+
+// KeySampler returns Sampler commenting fraction of queries given by rate in range [0, 1],
+// deciding deterministically by hash of key returned from fn. All queries sharing the same key,
+// like queries of a single request, are either commented or not.
+// Queries without key are sampled randomly.
+func KeySampler(rate float64, fn KeyFunc) Sampler {
+	return SamplerFunc(func(ctx context.Context, query string) bool {
+		key, ok := fn(ctx)
+		if !ok {
+			return sampleRandom(rate)
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		return sampleUint64(h.Sum64(), rate)
+	})
+}
+
+func sampleRandom(rate float64) bool {
+	return sampleUint64(rand.Uint64(), rate)
+}
+
+// sampleUint64 reports whether uniformly distributed v falls into fraction given by rate.
+func sampleUint64(v uint64, rate float64) bool {
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	}
+	return v < uint64(rate*math.MaxUint64)
+}
+
+func (c *commenter) sampled(ctx context.Context, query string) bool {
+	if c.sampler == nil {
+		return true
+	}
+	for _, always := range c.alwaysComment {
+		if always(ctx, query) {
+			return true
+		}
+	}
+	return c.sampler.Sample(ctx, query)
+}
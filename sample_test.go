@@ -0,0 +1,124 @@
+package sqlcommenter
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRateSampler(t *testing.T) {
+	cases := []struct {
+		rate    float64
+		wantMin int
+		wantMax int
+	}{
+		{
+			rate:    0,
+			wantMin: 0,
+			wantMax: 0,
+		},
+		{
+			rate:    1,
+			wantMin: 10000,
+			wantMax: 10000,
+		},
+		{
+			rate:    0.5,
+			wantMin: 4500,
+			wantMax: 5500,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(strconv.FormatFloat(cs.rate, 'f', -1, 64), func(t *testing.T) {
+			smp := RateSampler(cs.rate)
+
+			var got int
+			for i := 0; i < 10000; i++ {
+				if smp.Sample(context.Background(), "SELECT 1") {
+					got++
+				}
+			}
+			if got < cs.wantMin || got > cs.wantMax {
+				t.Errorf("got '%v', want between '%v' and '%v'", got, cs.wantMin, cs.wantMax)
+			}
+		})
+	}
+}
+
+func TestKeySampler(t *testing.T) {
+	smp := KeySampler(0.3, func(ctx context.Context) (string, bool) {
+		attrs := AttrsFromContext(ctx)
+		key, ok := attrs["request"]
+		return key, ok
+	})
+
+	var got int
+	for i := 0; i < 10000; i++ {
+		ctx := ContextWithAttrs(context.Background(), Attrs{"request": strconv.Itoa(i)})
+		want := smp.Sample(ctx, "SELECT 1")
+		for j := 0; j < 5; j++ {
+			if smp.Sample(ctx, "SELECT 2") != want {
+				t.Fatalf("got different decision for key '%v'", i)
+			}
+		}
+		if want {
+			got++
+		}
+	}
+	if got < 2500 || got > 3500 {
+		t.Errorf("got '%v', want between '%v' and '%v'", got, 2500, 3500)
+	}
+}
+
+func TestCommentSampler(t *testing.T) {
+	never := SamplerFunc(func(ctx context.Context, query string) bool {
+		return false
+	})
+	isUpdate := func(ctx context.Context, query string) bool {
+		return strings.HasPrefix(query, "UPDATE")
+	}
+
+	cases := []struct {
+		name  string
+		query string
+		opts  []Option
+		want  string
+	}{
+		{
+			name:  "sampled",
+			query: "SELECT 1",
+			opts:  []Option{WithSampler(RateSampler(1))},
+			want:  "SELECT 1 /*key='value'*/",
+		},
+		{
+			name:  "not sampled",
+			query: "SELECT 1",
+			opts:  []Option{WithSampler(never)},
+			want:  "SELECT 1",
+		},
+		{
+			name:  "not sampled always comment",
+			query: "UPDATE users SET name = 'joe'",
+			opts:  []Option{WithSampler(never), WithAlwaysComment(isUpdate)},
+			want:  "UPDATE users SET name = 'joe' /*key='value'*/",
+		},
+		{
+			name:  "not sampled always comment not matching",
+			query: "SELECT 1",
+			opts:  []Option{WithSampler(never), WithAlwaysComment(isUpdate)},
+			want:  "SELECT 1",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			opts := append([]Option{WithAttrPairs("key", "value")}, cs.opts...)
+			got := Comment(context.Background(), cs.query, opts...)
+			if want := cs.want; want != got {
+				t.Errorf("got '%v', want '%v'", got, want)
+			}
+		})
+	}
+}
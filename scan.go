@@ -0,0 +1,341 @@
+package sqlcommenter
+
+import (
+	"strings"
+)
+
+// commentSpan represents position of comment in query.
+type commentSpan struct {
+	start int
+	end   int
+	line  bool
+	hint  bool
+}
+
+// blockBody returns content of block comment, reporting false for line or unterminated comments.
+func (c commentSpan) blockBody(query string) (string, bool) {
+	if c.line || c.end-c.start < len(commentStart)+len(commentEnd) || query[c.end-len(commentEnd):c.end] != commentEnd {
+		return "", false
+	}
+	return query[c.start+len(commentStart) : c.end-len(commentEnd)], true
+}
+
+// scanner finds comments in query while skipping string literals,
+// quoted identifiers and dollar-quoted bodies as understood by dialect.
+type scanner struct {
+	query string
+	pos   int
+	rules dialectRules
+}
+
+func newScanner(query string, d Dialect) *scanner {
+	return &scanner{query: query, rules: d.rules()}
+}
+
+// next returns next comment found in query.
+func (s *scanner) next() (commentSpan, bool) {
+	for s.pos < len(s.query) {
+		switch c := s.query[s.pos]; {
+		case c == '\'':
+			s.skipString()
+		case c == '"':
+			s.skipQuoted(c, s.rules.backslashEscapes)
+		case c == '`' && s.rules.backticks:
+			s.skipQuoted(c, false)
+		case c == '[' && s.rules.brackets:
+			s.skipBracketed()
+		case c == '$' && s.rules.dollarQuotes:
+			s.skipDollarQuoted()
+		case c == '-' && s.peek(1) == '-' && (!s.rules.dashSpaceComments || s.peek(2) <= ' '):
+			return s.lineComment(), true
+		case c == '#' && s.rules.hashComments:
+			return s.lineComment(), true
+		case c == '/' && s.peek(1) == '*':
+			return s.blockComment(), true
+		default:
+			s.pos++
+		}
+	}
+	return commentSpan{}, false
+}
+
+func (s *scanner) peek(n int) byte {
+	if s.pos+n < len(s.query) {
+		return s.query[s.pos+n]
+	}
+	return 0
+}
+
+// prefixed reports whether literal at current position is prefixed by one of letters,
+// like E'text' strings in PostgreSQL or q'[text]' strings in Oracle.
+func (s *scanner) prefixed(letters string) bool {
+	i := s.pos - 1
+	if i < 0 || strings.IndexByte(letters, s.query[i]) == -1 {
+		return false
+	}
+	// national character literal, like nq'[text]'
+	if i > 0 && (s.query[i-1] == 'n' || s.query[i-1] == 'N') {
+		i--
+	}
+	return i == 0 || !isIdentByte(s.query[i-1])
+}
+
+func (s *scanner) skipString() {
+	switch {
+	case s.rules.qQuotes && s.prefixed("qQ"):
+		s.skipQQuoted()
+	case s.rules.escapeStrings && s.prefixed("eE"):
+		s.skipQuoted('\'', true)
+	default:
+		s.skipQuoted('\'', s.rules.backslashEscapes)
+	}
+}
+
+func (s *scanner) skipQuoted(quote byte, backslash bool) {
+	s.pos++
+	for s.pos < len(s.query) {
+		switch s.query[s.pos] {
+		case '\\':
+			if backslash {
+				s.pos += 2
+				continue
+			}
+		case quote:
+			// doubled quote is an escaped quote
+			if s.peek(1) == quote {
+				s.pos += 2
+				continue
+			}
+			s.pos++
+			return
+		}
+		s.pos++
+	}
+}
+
+// skipQQuoted skips Oracle alternative quoting, like q'[it's]'.
+func (s *scanner) skipQQuoted() {
+	delim := s.peek(1)
+	if delim == 0 || isSpace(delim) {
+		s.skipQuoted('\'', false)
+		return
+	}
+	if idx := strings.IndexByte("[{(<", delim); idx != -1 {
+		delim = "]})>"[idx]
+	}
+
+	s.pos += 2
+	if idx := strings.Index(s.query[s.pos:], string([]byte{delim, '\''})); idx != -1 {
+		s.pos += idx + 2
+	} else {
+		s.pos = len(s.query)
+	}
+}
+
+func (s *scanner) skipBracketed() {
+	if idx := strings.IndexByte(s.query[s.pos:], ']'); idx != -1 {
+		s.pos += idx + 1
+	} else {
+		s.pos = len(s.query)
+	}
+}
+
+func (s *scanner) skipDollarQuoted() {
+	if s.pos > 0 && isIdentByte(s.query[s.pos-1]) {
+		s.pos++
+		return
+	}
+
+	end := s.pos + 1
+	for end < len(s.query) && s.query[end] != '$' {
+		if !isIdentByte(s.query[end]) || (end == s.pos+1 && isDigit(s.query[end])) {
+			s.pos++
+			return
+		}
+		end++
+	}
+	if end >= len(s.query) {
+		s.pos++
+		return
+	}
+
+	tag := s.query[s.pos : end+1]
+	s.pos = end + 1
+	if idx := strings.Index(s.query[s.pos:], tag); idx != -1 {
+		s.pos += idx + len(tag)
+	} else {
+		s.pos = len(s.query)
+	}
+}
+
+func (s *scanner) lineComment() commentSpan {
+	span := commentSpan{start: s.pos, line: true}
+	for s.pos < len(s.query) && s.query[s.pos] != '\n' {
+		s.pos++
+	}
+	span.end = s.pos
+	return span
+}
+
+func (s *scanner) blockComment() commentSpan {
+	span := commentSpan{start: s.pos}
+	for _, prefix := range s.rules.hints {
+		if strings.HasPrefix(s.query[s.pos:], prefix) {
+			span.hint = true
+		}
+	}
+
+	s.pos += len(commentStart)
+	depth := 1
+	for s.pos < len(s.query) {
+		switch {
+		case s.rules.nestedComments && s.query[s.pos] == '/' && s.peek(1) == '*':
+			depth++
+			s.pos += len(commentStart)
+		case s.query[s.pos] == '*' && s.peek(1) == '/':
+			depth--
+			s.pos += len(commentEnd)
+			if depth == 0 {
+				span.end = s.pos
+				return span
+			}
+		default:
+			s.pos++
+		}
+	}
+	span.end = len(s.query)
+	return span
+}
+
+// hasComment reports whether query contains any comment other than hints.
+func (d Dialect) hasComment(query string) bool {
+	scn := newScanner(query, d)
+	for {
+		span, ok := scn.next()
+		if !ok {
+			return false
+		}
+		if !span.hint {
+			return true
+		}
+	}
+}
+
+// splitTrailer splits query into body and trailer consisting of trailing whitespace,
+// or starting with semicolon which ends the query followed only by semicolons, whitespace and comments.
+func (d Dialect) splitTrailer(query string) (string, string) {
+	if strings.IndexByte(query, ';') == -1 {
+		body := trimRightSpace(query)
+		return body, query[len(body):]
+	}
+
+	// semi is position of the first semicolon following the last statement text outside of comments
+	semi := -1
+	code := func(from int, to int) {
+		for i := from; i < to; i++ {
+			switch c := query[i]; {
+			case c == ';':
+				if semi == -1 {
+					semi = i
+				}
+			case !isSpace(c):
+				semi = -1
+			}
+		}
+	}
+
+	pos := 0
+	scn := newScanner(query, d)
+	for {
+		span, ok := scn.next()
+		if !ok {
+			break
+		}
+		code(pos, span.start)
+		pos = span.end
+	}
+	code(pos, len(query))
+
+	end := len(query)
+	if semi != -1 {
+		end = semi
+	}
+	body := trimRightSpace(query[:end])
+	return body, query[len(body):]
+}
+
+// leadingHints returns position in query following hints which start the query.
+func (d Dialect) leadingHints(query string) int {
+	end := 0
+	scn := newScanner(query, d)
+	for {
+		span, ok := scn.next()
+		if !ok || !span.hint || span.start != len(query)-len(trimLeftSpace(query[end:])) {
+			return end
+		}
+		end = span.end
+	}
+}
+
+// leadingComment returns the block comment which starts the query, ignoring leading whitespace and hints.
+func (d Dialect) leadingComment(query string) (commentSpan, bool) {
+	start := d.leadingHints(query)
+	start = len(query) - len(trimLeftSpace(query[start:]))
+
+	scn := newScanner(query, d)
+	scn.pos = start
+	span, ok := scn.next()
+	if !ok || span.line || span.hint || span.start != start {
+		return commentSpan{}, false
+	}
+	return span, true
+}
+
+// trailingComment returns the comment which ends the query, ignoring trailing whitespace.
+// Hints are not reported.
+func (d Dialect) trailingComment(query string) (commentSpan, bool) {
+	end := len(trimRightSpace(query))
+
+	var last commentSpan
+	var found bool
+	scn := newScanner(query, d)
+	for {
+		span, ok := scn.next()
+		if !ok {
+			break
+		}
+		last, found = span, true
+	}
+	if !found || last.hint || last.end != end {
+		return commentSpan{}, false
+	}
+	return last, true
+}
+
+func isIdentByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || isDigit(c) || c == '_' || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func trimLeftSpace(s string) string {
+	i := 0
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+	return s[i:]
+}
+
+func trimRightSpace(s string) string {
+	i := len(s)
+	for i > 0 && isSpace(s[i-1]) {
+		i--
+	}
+	return s[:i]
+}
@@ -0,0 +1,185 @@
+package sqlcommenter
+
+import (
+	"testing"
+)
+
+func TestHasComment(t *testing.T) {
+	cases := []struct {
+		query string
+		want  bool
+	}{
+		{
+			query: "",
+			want:  false,
+		},
+		{
+			query: "SELECT 1",
+			want:  false,
+		},
+		{
+			query: "SELECT 1 /* comment */",
+			want:  true,
+		},
+		{
+			query: "SELECT /*+ IndexScan(t) */ * FROM t",
+			want:  true,
+		},
+		{
+			query: "SELECT 1 -- comment",
+			want:  true,
+		},
+		{
+			query: "SELECT '/* not a comment */'",
+			want:  false,
+		},
+		{
+			query: "SELECT 'it''s /* not */ a comment'",
+			want:  false,
+		},
+		{
+			query: `SELECT "weird/*column" FROM t`,
+			want:  false,
+		},
+		{
+			query: "SELECT $$ -- not a comment $$",
+			want:  false,
+		},
+		{
+			query: "SELECT $body$ /* not a comment */ $body$",
+			want:  false,
+		},
+		{
+			query: "SELECT $1 /* comment */",
+			want:  true,
+		},
+		{
+			query: "SELECT a$b /* comment */",
+			want:  true,
+		},
+		{
+			query: "SELECT 1 - -1",
+			want:  false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.query, func(t *testing.T) {
+			if got := DialectGeneric.hasComment(cs.query); cs.want != got {
+				t.Errorf("got '%v', want '%v'", got, cs.want)
+			}
+		})
+	}
+}
+
+func TestTrailingComment(t *testing.T) {
+	cases := []struct {
+		query  string
+		want   commentSpan
+		wantOK bool
+	}{
+		{
+			query: "SELECT 1",
+		},
+		{
+			query: "SELECT /* comment */ 1",
+		},
+		{
+			query: "SELECT 1 '/* literal */'",
+		},
+		{
+			query:  "SELECT 1 /* comment */",
+			want:   commentSpan{start: 9, end: 22},
+			wantOK: true,
+		},
+		{
+			query:  "SELECT 1 /* first */ /* second */  \n",
+			want:   commentSpan{start: 21, end: 33},
+			wantOK: true,
+		},
+		{
+			query:  "SELECT 1 -- comment\n",
+			want:   commentSpan{start: 9, end: 19, line: true},
+			wantOK: true,
+		},
+		{
+			query:  "SELECT 1 /* unterminated",
+			want:   commentSpan{start: 9, end: 24},
+			wantOK: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.query, func(t *testing.T) {
+			got, ok := DialectGeneric.trailingComment(cs.query)
+			if ok != cs.wantOK {
+				t.Fatalf("got '%v', want '%v'", ok, cs.wantOK)
+			}
+			if got != cs.want {
+				t.Errorf("got '%v', want '%v'", got, cs.want)
+			}
+		})
+	}
+}
+
+func TestSplitTrailer(t *testing.T) {
+	cases := []struct {
+		query       string
+		wantBody    string
+		wantTrailer string
+	}{
+		{
+			query: "",
+		},
+		{
+			query:    "SELECT 1",
+			wantBody: "SELECT 1",
+		},
+		{
+			query:       "SELECT 1;",
+			wantBody:    "SELECT 1",
+			wantTrailer: ";",
+		},
+		{
+			query:       "SELECT 1 ;\n; ",
+			wantBody:    "SELECT 1",
+			wantTrailer: " ;\n; ",
+		},
+		{
+			query:       "SELECT 1 -- comment;\n",
+			wantBody:    "SELECT 1 -- comment;",
+			wantTrailer: "\n",
+		},
+		{
+			query:       "SELECT 1 /* comment; */;",
+			wantBody:    "SELECT 1 /* comment; */",
+			wantTrailer: ";",
+		},
+		{
+			query:       "SELECT 1; -- comment",
+			wantBody:    "SELECT 1",
+			wantTrailer: "; -- comment",
+		},
+		{
+			query:       "SELECT 1 ; /* comment */ ;\n",
+			wantBody:    "SELECT 1",
+			wantTrailer: " ; /* comment */ ;\n",
+		},
+		{
+			query:    "SELECT 1; SELECT ';'",
+			wantBody: "SELECT 1; SELECT ';'",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.query, func(t *testing.T) {
+			body, trailer := DialectGeneric.splitTrailer(cs.query)
+			if body != cs.wantBody {
+				t.Errorf("got '%v', want '%v'", body, cs.wantBody)
+			}
+			if trailer != cs.wantTrailer {
+				t.Errorf("got '%v', want '%v'", trailer, cs.wantTrailer)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package sqlcommenter
+
+import (
+	"context"
+	"database/sql/driver"
+	"slices"
+)
+
+var _ driver.SessionResetter = (*sessionResetter)(nil)
+
+type sessionStatement struct {
+	fn   StatementFunc
+	prov AttrProvider
+}
+
+// initSession executes session statements on conn and returns them.
+// Drivers which do not implement driver.ExecerContext are skipped.
+func (c *commenter) initSession(ctx context.Context, conn driver.Conn) ([]string, error) {
+	if len(c.sessionStmts) == 0 {
+		return nil, nil
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		return nil, nil
+	}
+
+	stmts := c.sessionStatements(ctx)
+	for _, stmt := range stmts {
+		if _, err := execer.ExecContext(ctx, stmt, nil); err != nil {
+			return nil, err
+		}
+	}
+	return stmts, nil
+}
+
+// sessionStatements builds session statements.
+func (c *commenter) sessionStatements(ctx context.Context) []string {
+	stmts := make([]string, 0, len(c.sessionStmts))
+	for _, stmt := range c.sessionStmts {
+		b := getBuilder()
+		b.AddAttrs(stmt.prov.GetAttrs(ctx))
+		c.filter(b)
+		attrs := b.toAttrs()
+		putBuilder(b)
+
+		stmts = append(stmts, stmt.fn(attrs))
+	}
+	return stmts
+}
+
+// sessionResetter resets session of conn and executes session statements again
+// when they differ from statements executed last time. database/sql resets session
+// whenever connection is reused, unchanged statements are skipped to avoid round trips.
+// Session statements may overwrite value set by volatile statement,
+// so it is sent again with the next query.
+type sessionResetter struct {
+	conn *connection
+}
+
+func (r *sessionResetter) ResetSession(ctx context.Context) error {
+	if resetter, ok := r.conn.Conn.(driver.SessionResetter); ok {
+		if err := resetter.ResetSession(ctx); err != nil {
+			return err
+		}
+	}
+	if _, ok := r.conn.Conn.(driver.ExecerContext); !ok {
+		return nil
+	}
+
+	stmts := r.conn.cmt.sessionStatements(ctx)
+	if slices.Equal(stmts, r.conn.lastSession) {
+		return nil
+	}
+
+	r.conn.lastSession = nil
+	r.conn.lastVolatile = ""
+	stmts, err := r.conn.cmt.initSession(ctx, r.conn.Conn)
+	if err != nil {
+		return err
+	}
+	r.conn.lastSession = stmts
+	return nil
+}
@@ -0,0 +1,29 @@
+package sqlcommenter
+
+import (
+	"context"
+	"strings"
+)
+
+// SkipPrefixes returns QueryPredicate matching queries starting with any of prefixes,
+// ignoring case and leading whitespace.
+func SkipPrefixes(prefixes ...string) QueryPredicate {
+	return func(ctx context.Context, query string) bool {
+		query = trimLeftSpace(query)
+		for _, prefix := range prefixes {
+			if len(query) >= len(prefix) && strings.EqualFold(query[:len(prefix)], prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (c *commenter) skipped(ctx context.Context, query string) bool {
+	for _, skip := range c.skip {
+		if skip(ctx, query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package sqlcommenter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSkipPrefixes(t *testing.T) {
+	cases := []struct {
+		query string
+		want  bool
+	}{
+		{
+			query: "",
+			want:  false,
+		},
+		{
+			query: "SELECT 1",
+			want:  false,
+		},
+		{
+			query: "LISTEN channel",
+			want:  true,
+		},
+		{
+			query: "  \n copy users FROM STDIN",
+			want:  true,
+		},
+		{
+			query: "CREATE INDEX idx ON users (name)",
+			want:  true,
+		},
+		{
+			query: "COP",
+			want:  false,
+		},
+	}
+
+	pred := SkipPrefixes("LISTEN", "COPY", "CREATE INDEX")
+	for _, cs := range cases {
+		t.Run(cs.query, func(t *testing.T) {
+			if got := pred(context.Background(), cs.query); cs.want != got {
+				t.Errorf("got '%v', want '%v'", got, cs.want)
+			}
+		})
+	}
+}
+
+func TestCommentSkip(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		ctx   func(ctx context.Context) context.Context
+		opts  []Option
+		want  string
+	}{
+		{
+			name:  "without comment",
+			query: "SELECT 1",
+			ctx:   WithoutComment,
+			want:  "SELECT 1",
+		},
+		{
+			name:  "skip predicate",
+			query: "LISTEN channel",
+			opts:  []Option{WithSkip(SkipPrefixes("LISTEN"))},
+			want:  "LISTEN channel",
+		},
+		{
+			name:  "skip predicate not matching",
+			query: "SELECT 1",
+			opts:  []Option{WithSkip(SkipPrefixes("LISTEN"))},
+			want:  "SELECT 1 /*key='value'*/",
+		},
+		{
+			name:  "comment options",
+			query: "SELECT 1",
+			ctx: func(ctx context.Context) context.Context {
+				return WithCommentOptions(ctx, WithAttrPairs("job", "cleanup"), WithPositionMode(PositionPrefix))
+			},
+			want: "/*job='cleanup',key='value'*/ SELECT 1",
+		},
+		{
+			name:  "stacked comment options",
+			query: "SELECT 1",
+			ctx: func(ctx context.Context) context.Context {
+				ctx = WithCommentOptions(ctx, WithAttrPairs("job", "cleanup"))
+				return WithCommentOptions(ctx, WithDeniedKeys("key"))
+			},
+			want: "SELECT 1 /*job='cleanup'*/",
+		},
+		{
+			name:  "comment options skip",
+			query: "CREATE TABLE users (id int)",
+			ctx: func(ctx context.Context) context.Context {
+				return WithCommentOptions(ctx, WithSkip(SkipPrefixes("CREATE")))
+			},
+			want: "CREATE TABLE users (id int)",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			ctx := context.Background()
+			if cs.ctx != nil {
+				ctx = cs.ctx(ctx)
+			}
+
+			opts := append([]Option{WithAttrPairs("key", "value")}, cs.opts...)
+			got := Comment(ctx, cs.query, opts...)
+			if want := cs.want; want != got {
+				t.Errorf("got '%v', want '%v'", got, want)
+			}
+		})
+	}
+}
+
+func TestCommentOptionsDoNotModifyCommenter(t *testing.T) {
+	cmt := NewCommenter(WithAttrPairs("key", "value"), WithDeniedKeys("secret"))
+
+	ctx := WithCommentOptions(context.Background(), WithAttrPairs("job", "cleanup"), WithDeniedKeys("key"))
+	if got, want := cmt.Comment(ctx, "SELECT 1"), "SELECT 1 /*job='cleanup'*/"; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+	if got, want := cmt.Comment(context.Background(), "SELECT 1"), "SELECT 1 /*key='value'*/"; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func TestCommentOptionsWithoutCommenterOptions(t *testing.T) {
+	ctx := WithCommentOptions(context.Background(), WithAttrPairs("job", "cleanup"))
+	if got, want := Comment(ctx, "SELECT 1"), "SELECT 1 /*job='cleanup'*/"; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
@@ -0,0 +1,22 @@
+package sqlcommenter
+
+import (
+	"bytes"
+	"strings"
+)
+
+// StatementFunc builds statement passing Attrs to database outside of query comment.
+type StatementFunc func(attrs Attrs) string
+
+// SetStatement returns StatementFunc building SET statement which assigns Attrs encoded
+// in sqlcommenter format to name. Name is not escaped and must come from trusted source.
+// For example SetStatement("application_name") builds:
+//
+//	SET application_name = 'key=''value'''
+func SetStatement(name string) StatementFunc {
+	return func(attrs Attrs) string {
+		var b bytes.Buffer
+		attrs.encode(&b)
+		return "SET " + name + " = '" + strings.ReplaceAll(b.String(), "'", "''") + "'"
+	}
+}
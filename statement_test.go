@@ -0,0 +1,37 @@
+package sqlcommenter
+
+import (
+	"testing"
+)
+
+func TestSetStatement(t *testing.T) {
+	cases := []struct {
+		name  string
+		attrs Attrs
+		want  string
+	}{
+		{
+			name: "no attrs",
+			want: "SET application_name = ''",
+		},
+		{
+			name:  "single attr",
+			attrs: Attrs{"key": "value"},
+			want:  "SET application_name = 'key=''value'''",
+		},
+		{
+			name:  "multiple attrs with escaping",
+			attrs: Attrs{"route": "/users/{id}", "name": "it's"},
+			want:  "SET application_name = 'name=''it%27s'',route=''%2Fusers%2F%7Bid%7D'''",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got := SetStatement("application_name")(cs.attrs)
+			if want := cs.want; want != got {
+				t.Errorf("got '%v', want '%v'", got, want)
+			}
+		})
+	}
+}
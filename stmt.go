@@ -0,0 +1,121 @@
+package sqlcommenter
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+)
+
+var (
+	_ driver.Stmt              = (*stmt)(nil)
+	_ driver.StmtExecContext   = (*stmt)(nil)
+	_ driver.StmtQueryContext  = (*stmt)(nil)
+	_ driver.NamedValueChecker = (*stmt)(nil)
+	_ driver.ColumnConverter   = (*converterStmt)(nil) // nolint:staticcheck
+)
+
+// errNamedParams is returned when named parameters are used with driver not supporting them.
+var errNamedParams = errors.New("sqlcommenter: driver does not support the use of named parameters")
+
+// newStmt wraps st prepared from query on conn,
+// exposing driver.ColumnConverter only when implemented by st.
+func newStmt(st driver.Stmt, query string, conn *connection) driver.Stmt {
+	s := &stmt{
+		Stmt:  st,
+		query: query,
+		conn:  conn,
+	}
+
+	if converter, ok := st.(driver.ColumnConverter); ok { // nolint:staticcheck
+		return &converterStmt{
+			stmt:      s,
+			converter: converter,
+		}
+	}
+	return s
+}
+
+type stmt struct {
+	driver.Stmt
+	// query is the original query without comment.
+	query string
+	conn  *connection
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := s.sendVolatile(ctx); err != nil {
+		return nil, err
+	}
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, args)
+	}
+
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values) // nolint:staticcheck
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := s.sendVolatile(ctx); err != nil {
+		return nil, err
+	}
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return queryer.QueryContext(ctx, args)
+	}
+
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(values) // nolint:staticcheck
+}
+
+// CheckNamedValue forwards to statement checker, falling back to connection checker
+// which is otherwise skipped by database/sql once statement implements driver.NamedValueChecker.
+func (s *stmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	if checker, ok := s.conn.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// sendVolatile sends volatile attrs of ctx, as statement may be executed
+// with different context than it was prepared with.
+func (s *stmt) sendVolatile(ctx context.Context) error {
+	if s.conn.cmt.volatileStmt == nil {
+		return nil
+	}
+	return s.conn.sendVolatile(ctx, s.conn.cmt.volatileAttrs(ctx, s.query))
+}
+
+// converterStmt is stmt exposing driver.ColumnConverter of wrapped statement.
+type converterStmt struct {
+	*stmt
+	converter driver.ColumnConverter // nolint:staticcheck
+}
+
+func (s *converterStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return s.converter.ColumnConverter(idx)
+}
+
+func namedValuesToValues(named []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(named))
+	for i, nv := range named {
+		if nv.Name != "" {
+			return nil, errNamedParams
+		}
+		values[i] = nv.Value
+	}
+	return values, nil
+}
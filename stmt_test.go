@@ -0,0 +1,246 @@
+package sqlcommenter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStmt(t *testing.T) {
+	cases := []struct {
+		name     string
+		conn     func() *mockLegacyConn
+		perform  func(t *testing.T, ctx context.Context, stmt *sql.Stmt)
+		wantArgs []driver.Value
+	}{
+		{
+			name: "ExecContext",
+			conn: func() *mockLegacyConn {
+				return &mockLegacyConn{}
+			},
+			perform: func(t *testing.T, ctx context.Context, stmt *sql.Stmt) {
+				_, err := stmt.ExecContext(ctx, 1, "joe")
+				assertNoError(t, err)
+			},
+			wantArgs: []driver.Value{int64(1), "joe"},
+		},
+		{
+			name: "QueryContext",
+			conn: func() *mockLegacyConn {
+				return &mockLegacyConn{}
+			},
+			perform: func(t *testing.T, ctx context.Context, stmt *sql.Stmt) {
+				rows, err := stmt.QueryContext(ctx, 1)
+				assertNoError(t, err)
+				_ = rows.Close()
+			},
+			wantArgs: []driver.Value{int64(1)},
+		},
+		{
+			name: "ExecContext named args",
+			conn: func() *mockLegacyConn {
+				return &mockLegacyConn{}
+			},
+			perform: func(t *testing.T, ctx context.Context, stmt *sql.Stmt) {
+				_, err := stmt.ExecContext(ctx, sql.Named("id", 1))
+				if !errors.Is(err, errNamedParams) {
+					t.Errorf("got '%v', want '%v'", err, errNamedParams)
+				}
+			},
+		},
+		{
+			name: "NamedValueChecker stmt",
+			conn: func() *mockLegacyConn {
+				return &mockLegacyConn{stmtChecker: true}
+			},
+			perform: func(t *testing.T, ctx context.Context, stmt *sql.Stmt) {
+				_, err := stmt.ExecContext(ctx, mockValue{})
+				assertNoError(t, err)
+			},
+			wantArgs: []driver.Value{"stmt"},
+		},
+		{
+			name: "NamedValueChecker conn",
+			conn: func() *mockLegacyConn {
+				return &mockLegacyConn{connChecker: true}
+			},
+			perform: func(t *testing.T, ctx context.Context, stmt *sql.Stmt) {
+				_, err := stmt.ExecContext(ctx, mockValue{})
+				assertNoError(t, err)
+			},
+			wantArgs: []driver.Value{"conn"},
+		},
+		{
+			name: "NamedValueChecker missing",
+			conn: func() *mockLegacyConn {
+				return &mockLegacyConn{}
+			},
+			perform: func(t *testing.T, ctx context.Context, stmt *sql.Stmt) {
+				if _, err := stmt.ExecContext(ctx, mockValue{}); err == nil {
+					t.Error("expected error, got nil")
+				}
+			},
+		},
+		{
+			name: "ColumnConverter",
+			conn: func() *mockLegacyConn {
+				return &mockLegacyConn{columnConverter: true}
+			},
+			perform: func(t *testing.T, ctx context.Context, stmt *sql.Stmt) {
+				_, err := stmt.ExecContext(ctx, 1)
+				assertNoError(t, err)
+			},
+			wantArgs: []driver.Value{"column"},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			ctx := context.Background()
+			conn := cs.conn()
+			db := sql.OpenDB(WrapConnector(&mockLegacyConnector{conn: conn}, WithAttrPairs("key", "value")))
+			defer db.Close()
+
+			stmt, err := db.PrepareContext(ctx, "SELECT 1")
+			assertNoError(t, err)
+			defer stmt.Close()
+
+			cs.perform(t, ctx, stmt)
+
+			if want := []string{"SELECT 1 /*key='value'*/"}; !reflect.DeepEqual(conn.prepared, want) {
+				t.Errorf("got '%v', want '%v'", conn.prepared, want)
+			}
+			if !reflect.DeepEqual(conn.args, cs.wantArgs) {
+				t.Errorf("got '%v', want '%v'", conn.args, cs.wantArgs)
+			}
+		})
+	}
+}
+
+func TestNewStmtColumnConverter(t *testing.T) {
+	st := newStmt(&mockLegacyStmt{conn: &mockLegacyConn{}}, "SELECT 1", nil)
+	if _, ok := st.(driver.ColumnConverter); ok { // nolint:staticcheck
+		t.Errorf("got '%T' implementing driver.ColumnConverter", st)
+	}
+
+	st = newStmt(&mockConverterStmt{}, "SELECT 1", nil)
+	if _, ok := st.(driver.ColumnConverter); !ok { // nolint:staticcheck
+		t.Errorf("got '%T' not implementing driver.ColumnConverter", st)
+	}
+}
+
+type mockLegacyConnector struct {
+	conn *mockLegacyConn
+}
+
+func (m *mockLegacyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if m.conn.connChecker {
+		return &mockCheckerConn{m.conn}, nil
+	}
+	return m.conn, nil
+}
+
+func (m *mockLegacyConnector) Driver() driver.Driver {
+	return m
+}
+
+func (m *mockLegacyConnector) Open(name string) (driver.Conn, error) {
+	return m.Connect(context.Background())
+}
+
+// mockLegacyConn implements only driver.Conn.
+type mockLegacyConn struct {
+	prepared        []string
+	args            []driver.Value
+	stmtChecker     bool
+	connChecker     bool
+	columnConverter bool
+}
+
+func (m *mockLegacyConn) Prepare(query string) (driver.Stmt, error) {
+	m.prepared = append(m.prepared, query)
+
+	st := &mockLegacyStmt{conn: m}
+	switch {
+	case m.stmtChecker:
+		return &mockCheckerStmt{st}, nil
+	case m.columnConverter:
+		return &mockConverterStmt{st}, nil
+	default:
+		return st, nil
+	}
+}
+
+func (m *mockLegacyConn) Begin() (driver.Tx, error) {
+	return &mockTx{}, nil
+}
+
+func (m *mockLegacyConn) Close() error {
+	return nil
+}
+
+type mockCheckerConn struct {
+	*mockLegacyConn
+}
+
+func (m *mockCheckerConn) CheckNamedValue(value *driver.NamedValue) error {
+	return checkMockValue(value, "conn")
+}
+
+// mockLegacyStmt implements only driver.Stmt.
+type mockLegacyStmt struct {
+	conn *mockLegacyConn
+}
+
+func (m *mockLegacyStmt) Close() error {
+	return nil
+}
+
+func (m *mockLegacyStmt) NumInput() int {
+	return -1
+}
+
+func (m *mockLegacyStmt) Exec(args []driver.Value) (driver.Result, error) {
+	m.conn.args = args
+	return driver.ResultNoRows, nil
+}
+
+func (m *mockLegacyStmt) Query(args []driver.Value) (driver.Rows, error) {
+	m.conn.args = args
+	return &mockRows{}, nil
+}
+
+type mockCheckerStmt struct {
+	*mockLegacyStmt
+}
+
+func (m *mockCheckerStmt) CheckNamedValue(value *driver.NamedValue) error {
+	return checkMockValue(value, "stmt")
+}
+
+type mockConverterStmt struct {
+	*mockLegacyStmt
+}
+
+func (m *mockConverterStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return mockConverter{}
+}
+
+type mockConverter struct{}
+
+func (mockConverter) ConvertValue(v any) (driver.Value, error) {
+	return "column", nil
+}
+
+type mockValue struct{}
+
+func checkMockValue(value *driver.NamedValue, converted string) error {
+	if _, ok := value.Value.(mockValue); !ok {
+		return driver.ErrSkip
+	}
+	value.Value = converted
+	return nil
+}
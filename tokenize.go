@@ -0,0 +1,138 @@
+package sqlcommenter
+
+import (
+	"strings"
+)
+
+// tokenKind is the kind of token returned by tokenizer.
+type tokenKind int
+
+const (
+	// tokenWord is a keyword or an unquoted identifier.
+	tokenWord tokenKind = iota
+	// tokenIdent is a quoted identifier.
+	tokenIdent
+	// tokenLiteral is a string, number or dollar-quoted literal.
+	tokenLiteral
+	// tokenPunct is a single punctuation or operator character.
+	tokenPunct
+)
+
+// token is a lexical token of query.
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// is reports whether token is keyword kw, ignoring case.
+func (t token) is(kw string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, kw)
+}
+
+// isAny reports whether token is any of keywords kws, ignoring case.
+func (t token) isAny(kws []string) bool {
+	for _, kw := range kws {
+		if t.is(kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPunct reports whether token is punctuation c.
+func (t token) isPunct(c byte) bool {
+	return t.kind == tokenPunct && t.text[0] == c
+}
+
+// isName reports whether token may be a part of object name.
+func (t token) isName() bool {
+	return t.kind == tokenWord || t.kind == tokenIdent
+}
+
+// tokenizer splits query into tokens, skipping whitespace and comments recognized by dialect.
+// It understands just enough SQL to find keywords and identifiers, it does not validate query.
+type tokenizer struct {
+	scanner
+	peeked *token
+}
+
+func newTokenizer(query string, d Dialect) *tokenizer {
+	return &tokenizer{scanner: *newScanner(query, d)}
+}
+
+// peek returns next token without consuming it.
+func (t *tokenizer) peek() (token, bool) {
+	if t.peeked == nil {
+		tok, ok := t.scan()
+		if !ok {
+			return token{}, false
+		}
+		t.peeked = &tok
+	}
+	return *t.peeked, true
+}
+
+// next returns next token.
+func (t *tokenizer) next() (token, bool) {
+	if t.peeked != nil {
+		tok := *t.peeked
+		t.peeked = nil
+		return tok, true
+	}
+	return t.scan()
+}
+
+func (t *tokenizer) scan() (token, bool) {
+	for t.pos < len(t.query) {
+		start := t.pos
+		switch c := t.query[t.pos]; {
+		case isSpace(c):
+			t.pos++
+		case c == '-' && t.scanner.peek(1) == '-' && (!t.rules.dashSpaceComments || t.scanner.peek(2) <= ' '):
+			t.lineComment()
+		case c == '#' && t.rules.hashComments:
+			t.lineComment()
+		case c == '/' && t.scanner.peek(1) == '*':
+			t.blockComment()
+		case c == '\'':
+			t.skipString()
+			return token{kind: tokenLiteral, text: t.query[start:t.pos]}, true
+		case c == '"':
+			t.skipQuoted(c, t.rules.backslashEscapes)
+			return token{kind: tokenIdent, text: unquoteIdent(t.query[start:t.pos], c, c)}, true
+		case c == '`':
+			t.skipQuoted(c, false)
+			return token{kind: tokenIdent, text: unquoteIdent(t.query[start:t.pos], c, c)}, true
+		case c == '[':
+			t.skipBracketed()
+			return token{kind: tokenIdent, text: unquoteIdent(t.query[start:t.pos], '[', ']')}, true
+		case c == '$' && t.rules.dollarQuotes:
+			t.skipDollarQuoted()
+			if t.pos-start > 1 {
+				return token{kind: tokenLiteral, text: t.query[start:t.pos]}, true
+			}
+			return token{kind: tokenPunct, text: t.query[start:t.pos]}, true
+		case isDigit(c):
+			for t.pos < len(t.query) && (isIdentByte(t.query[t.pos]) || t.query[t.pos] == '.') {
+				t.pos++
+			}
+			return token{kind: tokenLiteral, text: t.query[start:t.pos]}, true
+		case isIdentByte(c):
+			for t.pos < len(t.query) && (isIdentByte(t.query[t.pos]) || t.query[t.pos] == '$') {
+				t.pos++
+			}
+			return token{kind: tokenWord, text: t.query[start:t.pos]}, true
+		default:
+			t.pos++
+			return token{kind: tokenPunct, text: t.query[start:t.pos]}, true
+		}
+	}
+	return token{}, false
+}
+
+// unquoteIdent strips quotes from quoted identifier s, unescaping doubled closing quotes.
+func unquoteIdent(s string, open byte, close byte) string {
+	s = strings.TrimPrefix(s, string(open))
+	s = strings.TrimSuffix(s, string(close))
+	return strings.ReplaceAll(s, string([]byte{close, close}), string(close))
+}
@@ -0,0 +1,157 @@
+package sqlcommenter
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// KeyTxIsolation is the attribute key of transaction isolation level added to transaction statements.
+const KeyTxIsolation = "isolation"
+
+// KeyTxReadOnly is the attribute key added to transaction statements of read-only transactions.
+const KeyTxReadOnly = "read_only"
+
+var _ driver.Tx = (*commentTx)(nil)
+
+var isolationLevels = map[sql.IsolationLevel]string{
+	sql.LevelReadUncommitted: "READ UNCOMMITTED",
+	sql.LevelReadCommitted:   "READ COMMITTED",
+	sql.LevelRepeatableRead:  "REPEATABLE READ",
+	sql.LevelSerializable:    "SERIALIZABLE",
+}
+
+// txSyntax describes transaction statements of dialect.
+type txSyntax struct {
+	begin    string
+	commit   string
+	rollback string
+	// isolation and readOnly report whether START TRANSACTION accepts isolation level and READ ONLY.
+	isolation bool
+	readOnly  bool
+}
+
+// txSyntaxes lists dialects supported by TxStatements.
+// Isolation levels set by SET TRANSACTION would outlive transaction in SQL Server,
+// Oracle starts transactions implicitly.
+var txSyntaxes = map[Dialect]txSyntax{
+	DialectGeneric: {
+		begin:     "BEGIN",
+		commit:    "COMMIT",
+		rollback:  "ROLLBACK",
+		isolation: true,
+		readOnly:  true,
+	},
+	DialectPostgres: {
+		begin:     "BEGIN",
+		commit:    "COMMIT",
+		rollback:  "ROLLBACK",
+		isolation: true,
+		readOnly:  true,
+	},
+	DialectMySQL: {
+		begin:    "START TRANSACTION",
+		commit:   "COMMIT",
+		rollback: "ROLLBACK",
+		readOnly: true,
+	},
+	DialectSQLite: {
+		begin:    "BEGIN",
+		commit:   "COMMIT",
+		rollback: "ROLLBACK",
+	},
+	DialectSQLServer: {
+		begin:    "BEGIN TRANSACTION",
+		commit:   "COMMIT TRANSACTION",
+		rollback: "ROLLBACK TRANSACTION",
+	},
+}
+
+// beginStatement returns statement starting transaction with opts in dialect d.
+func beginStatement(d Dialect, opts driver.TxOptions) (string, bool) {
+	syntax, ok := txSyntaxes[d]
+	if !ok {
+		return "", false
+	}
+
+	level := sql.IsolationLevel(opts.Isolation)
+	if level == sql.LevelDefault && !opts.ReadOnly {
+		return syntax.begin, true
+	}
+	if (level != sql.LevelDefault && !syntax.isolation) || (opts.ReadOnly && !syntax.readOnly) {
+		return "", false
+	}
+
+	stmt := "START TRANSACTION"
+	if level != sql.LevelDefault {
+		name, ok := isolationLevels[level]
+		if !ok {
+			return "", false
+		}
+		stmt += " ISOLATION LEVEL " + name
+	}
+	if opts.ReadOnly {
+		if level != sql.LevelDefault {
+			stmt += ","
+		}
+		stmt += " READ ONLY"
+	}
+	return stmt, true
+}
+
+// txContext returns ctx carrying attrs describing opts, detached from cancellation of ctx
+// so that transaction can always be finished.
+func txContext(ctx context.Context, opts driver.TxOptions) context.Context {
+	ctx = context.WithoutCancel(ctx)
+
+	attrs := make(Attrs, 2)
+	if level := sql.IsolationLevel(opts.Isolation); level != sql.LevelDefault {
+		attrs[KeyTxIsolation] = level.String()
+	}
+	if opts.ReadOnly {
+		attrs[KeyTxReadOnly] = "true"
+	}
+	if len(attrs) == 0 {
+		return ctx
+	}
+	return ContextWithAttrs(ctx, attrs)
+}
+
+func beginCommentTx(ctx context.Context, execer driver.ExecerContext, cmt *commenter, opts driver.TxOptions) (driver.Tx, bool, error) {
+	stmt, ok := beginStatement(cmt.dialect, opts)
+	if !ok {
+		return nil, false, nil
+	}
+
+	tx := &commentTx{
+		ctx:    txContext(ctx, opts),
+		execer: execer,
+		cmt:    cmt,
+		syntax: txSyntaxes[cmt.dialect],
+	}
+	if _, err := execer.ExecContext(ctx, cmt.comment(tx.ctx, stmt), nil); err != nil {
+		return nil, true, err
+	}
+	return tx, true, nil
+}
+
+// commentTx finishes transaction by issuing commented statements.
+type commentTx struct {
+	ctx    context.Context
+	execer driver.ExecerContext
+	cmt    *commenter
+	syntax txSyntax
+}
+
+func (t *commentTx) Commit() error {
+	return t.exec(t.syntax.commit)
+}
+
+func (t *commentTx) Rollback() error {
+	return t.exec(t.syntax.rollback)
+}
+
+func (t *commentTx) exec(stmt string) error {
+	_, err := t.execer.ExecContext(t.ctx, t.cmt.comment(t.ctx, stmt), nil)
+	return err
+}
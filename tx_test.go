@@ -0,0 +1,108 @@
+package sqlcommenter
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestBeginStatement(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		opts    driver.TxOptions
+		want    string
+		wantOK  bool
+	}{
+		{
+			name:   "default",
+			want:   "BEGIN",
+			wantOK: true,
+		},
+		{
+			name:   "isolation level",
+			opts:   driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelReadCommitted)},
+			want:   "START TRANSACTION ISOLATION LEVEL READ COMMITTED",
+			wantOK: true,
+		},
+		{
+			name:   "read only",
+			opts:   driver.TxOptions{ReadOnly: true},
+			want:   "START TRANSACTION READ ONLY",
+			wantOK: true,
+		},
+		{
+			name:   "isolation level and read only",
+			opts:   driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelRepeatableRead), ReadOnly: true},
+			want:   "START TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY",
+			wantOK: true,
+		},
+		{
+			name: "unsupported isolation level",
+			opts: driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelLinearizable)},
+		},
+		{
+			name:    "postgres isolation level and read only",
+			dialect: DialectPostgres,
+			opts:    driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSerializable), ReadOnly: true},
+			want:    "START TRANSACTION ISOLATION LEVEL SERIALIZABLE, READ ONLY",
+			wantOK:  true,
+		},
+		{
+			name:    "mysql default",
+			dialect: DialectMySQL,
+			want:    "START TRANSACTION",
+			wantOK:  true,
+		},
+		{
+			name:    "mysql read only",
+			dialect: DialectMySQL,
+			opts:    driver.TxOptions{ReadOnly: true},
+			want:    "START TRANSACTION READ ONLY",
+			wantOK:  true,
+		},
+		{
+			name:    "mysql isolation level",
+			dialect: DialectMySQL,
+			opts:    driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelReadCommitted)},
+		},
+		{
+			name:    "sqlite default",
+			dialect: DialectSQLite,
+			want:    "BEGIN",
+			wantOK:  true,
+		},
+		{
+			name:    "sqlite read only",
+			dialect: DialectSQLite,
+			opts:    driver.TxOptions{ReadOnly: true},
+		},
+		{
+			name:    "sqlserver default",
+			dialect: DialectSQLServer,
+			want:    "BEGIN TRANSACTION",
+			wantOK:  true,
+		},
+		{
+			name:    "sqlserver isolation level",
+			dialect: DialectSQLServer,
+			opts:    driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSnapshot)},
+		},
+		{
+			name:    "oracle default",
+			dialect: DialectOracle,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got, ok := beginStatement(cs.dialect, cs.opts)
+			if ok != cs.wantOK {
+				t.Fatalf("got '%v', want '%v'", ok, cs.wantOK)
+			}
+			if got != cs.want {
+				t.Errorf("got '%v', want '%v'", got, cs.want)
+			}
+		})
+	}
+}
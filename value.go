@@ -0,0 +1,70 @@
+package sqlcommenter
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// String returns Attr with string value.
+func String(key string, value string) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Int returns Attr with value formatted in base 10.
+func Int(key string, value int) Attr {
+	return Int64(key, int64(value))
+}
+
+// Int64 returns Attr with value formatted in base 10.
+func Int64(key string, value int64) Attr {
+	return Attr{Key: key, Value: strconv.FormatInt(value, 10)}
+}
+
+// Uint64 returns Attr with value formatted in base 10.
+func Uint64(key string, value uint64) Attr {
+	return Attr{Key: key, Value: strconv.FormatUint(value, 10)}
+}
+
+// Float64 returns Attr with value formatted using the smallest number of digits necessary to represent it.
+func Float64(key string, value float64) Attr {
+	return Attr{Key: key, Value: strconv.FormatFloat(value, 'g', -1, 64)}
+}
+
+// Bool returns Attr with value formatted as true or false.
+func Bool(key string, value bool) Attr {
+	return Attr{Key: key, Value: strconv.FormatBool(value)}
+}
+
+// Time returns Attr with value converted to UTC and formatted as RFC 3339 with nanoseconds,
+// trailing zeros of fraction are removed.
+func Time(key string, value time.Time) Attr {
+	return Attr{Key: key, Value: value.UTC().Format(time.RFC3339Nano)}
+}
+
+// Duration returns Attr with value formatted by time.Duration.String, like 1m30s.
+func Duration(key string, value time.Duration) Attr {
+	return Attr{Key: key, Value: value.String()}
+}
+
+// Stringer returns Attr with value formatted by fmt.Stringer, nil value is formatted as empty string.
+func Stringer(key string, value fmt.Stringer) Attr {
+	if value == nil {
+		return Attr{Key: key}
+	}
+	return Attr{Key: key, Value: value.String()}
+}
+
+// AttrsOf builds Attrs from multiple Attr, later Attr wins for duplicate keys.
+func AttrsOf(attrs ...Attr) Attrs {
+	a := make(Attrs, len(attrs))
+	a.Set(attrs...)
+	return a
+}
+
+// Set sets values of attrs.
+func (a Attrs) Set(attrs ...Attr) {
+	for _, attr := range attrs {
+		a[attr.Key] = attr.Value
+	}
+}
@@ -0,0 +1,97 @@
+package sqlcommenter
+
+import (
+	"context"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAttrValues(t *testing.T) {
+	cases := []struct {
+		name string
+		attr Attr
+		want string
+	}{
+		{
+			name: "string",
+			attr: String("key", "value"),
+			want: "value",
+		},
+		{
+			name: "int",
+			attr: Int("key", -42),
+			want: "-42",
+		},
+		{
+			name: "int64",
+			attr: Int64("key", 9007199254740993),
+			want: "9007199254740993",
+		},
+		{
+			name: "uint64",
+			attr: Uint64("key", 18446744073709551615),
+			want: "18446744073709551615",
+		},
+		{
+			name: "float64",
+			attr: Float64("key", 0.25),
+			want: "0.25",
+		},
+		{
+			name: "float64 large",
+			attr: Float64("key", 1e21),
+			want: "1e+21",
+		},
+		{
+			name: "bool",
+			attr: Bool("key", true),
+			want: "true",
+		},
+		{
+			name: "time",
+			attr: Time("key", time.Date(2024, 3, 1, 12, 30, 0, 500000000, time.FixedZone("CET", 3600))),
+			want: "2024-03-01T11:30:00.5Z",
+		},
+		{
+			name: "duration",
+			attr: Duration("key", 90*time.Second+5*time.Millisecond),
+			want: "1m30.005s",
+		},
+		{
+			name: "stringer",
+			attr: Stringer("key", netip.MustParseAddr("10.0.0.1")),
+			want: "10.0.0.1",
+		},
+		{
+			name: "nil stringer",
+			attr: Stringer("key", nil),
+			want: "",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			if got := cs.attr; got.Key != "key" || got.Value != cs.want {
+				t.Errorf("got '%v', want '%v'", got, Attr{Key: "key", Value: cs.want})
+			}
+		})
+	}
+}
+
+func TestAttrsOf(t *testing.T) {
+	got := AttrsOf(Int("retry", 1), Bool("cached", false), Int("retry", 2))
+	want := Attrs{"retry": "2", "cached": "false"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
+
+func TestAttrsOfComment(t *testing.T) {
+	ctx := ContextWithAttrs(context.Background(), AttrsOf(Duration("timeout", 5*time.Second)))
+	got := Comment(ctx, "SELECT 1", WithAttrs(AttrsOf(String("app", "api"), Int("shard", 3))))
+	if want := "SELECT 1 /*app='api',shard='3',timeout='5s'*/"; want != got {
+		t.Errorf("got '%v', want '%v'", got, want)
+	}
+}
@@ -0,0 +1,50 @@
+package sqlcommenter
+
+import (
+	"database/sql/driver"
+)
+
+var _ driver.Tx = (*volatileTx)(nil)
+
+// split moves volatile attrs from b to volatile.
+func (c *commenter) split(b *AttrBuilder, volatile *AttrBuilder) {
+	if len(c.volatileKeys) == 0 || len(b.attrs) == 0 {
+		return
+	}
+
+	b.remove(func(attr Attr) bool {
+		if _, ok := c.volatileKeys[attr.Key]; !ok {
+			return false
+		}
+		volatile.attrs = append(volatile.attrs, builderAttr{Attr: attr})
+		return true
+	})
+}
+
+// volatileTx forgets volatile statement of connection when transaction is rolled back,
+// as database may revert statements executed in transaction, for example SET in PostgreSQL.
+type volatileTx struct {
+	driver.Tx
+	conn *connection
+}
+
+func (t *volatileTx) Commit() error {
+	t.conn.inTx = false
+	err := t.Tx.Commit()
+	if err != nil {
+		t.conn.lastVolatile = ""
+	}
+	return err
+}
+
+func (t *volatileTx) Rollback() error {
+	t.conn.inTx = false
+	t.conn.lastVolatile = ""
+	return t.Tx.Rollback()
+}
+
+// isRollback reports whether query is ROLLBACK statement.
+func isRollback(query string) bool {
+	tok, ok := newTokenizer(query, DialectGeneric).next()
+	return ok && tok.is("ROLLBACK")
+}